@@ -12,6 +12,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Uptycs/kubequery/internal/k8s"
@@ -19,11 +20,14 @@ import (
 	"github.com/Uptycs/kubequery/internal/k8s/apps"
 	"github.com/Uptycs/kubequery/internal/k8s/autoscaling"
 	"github.com/Uptycs/kubequery/internal/k8s/batch"
+	"github.com/Uptycs/kubequery/internal/k8s/cis"
 	"github.com/Uptycs/kubequery/internal/k8s/core"
 	"github.com/Uptycs/kubequery/internal/k8s/discovery"
+	"github.com/Uptycs/kubequery/internal/k8s/metrics"
 	"github.com/Uptycs/kubequery/internal/k8s/networking"
 	"github.com/Uptycs/kubequery/internal/k8s/policy"
 	"github.com/Uptycs/kubequery/internal/k8s/rbac"
+	"github.com/Uptycs/kubequery/internal/k8s/security"
 	"github.com/Uptycs/kubequery/internal/k8s/storage"
 
 	"github.com/kolide/osquery-go"
@@ -34,79 +38,133 @@ var (
 	socket   = flag.String("socket", "", "Path to the extensions UNIX domain socket")
 	timeout  = flag.Int("timeout", 3, "Seconds to wait for autoloaded extensions")
 	interval = flag.Int("interval", 3, "Seconds delay between connectivity checks")
+
+	clusterUID       = flag.String("cluster-uid", "", "Cluster UID to use instead of identifying it from the API server")
+	clusterName      = flag.String("cluster-name", "", "Cluster name reported in cluster_name column of all tables")
+	clusterUIDSource = flag.String("cluster-uid-source", k8s.ClusterUIDSourceAuto, "Source of cluster UID when --cluster-uid is not set: auto, kube-system or api-server")
+
+	as               = flag.String("as", "", "Username to impersonate for all API server requests")
+	asGroup          = flag.String("as-group", "", "Comma separated groups to impersonate for all API server requests")
+	impersonateAllow = flag.String("impersonate-allow", "", "Comma separated users that can be impersonated with impersonate_user column. A trailing * matches any suffix. impersonate_user is disabled if not set")
+
+	secretSalt = flag.String("secret-salt", "", "Salt used to fingerprint secret values. Random per process if not set")
+
+	allowedRegistries = flag.String("allowed-registries", "", "Comma separated registries, optionally with repository prefix, that images are expected to be pulled from")
+
+	metricsInterval  = flag.Int("metrics-interval", 60, "Seconds between resource usage samples used for recommendations. 0 disables sampling")
+	metricsRetention = flag.Int("metrics-retention", 24, "Hours of resource usage samples kept in memory")
 )
 
+// newPlugin creates an Osquery table plugin that fails queries until kubernetes client is ready.
+// All tables support impersonating a user with hidden impersonate_user column constraint.
+func newPlugin(name string, columns []table.ColumnDefinition, gen table.GenerateFunc) osquery.OsqueryPlugin {
+	return k8s.HideImpersonateColumn(table.NewPlugin(name, k8s.ImpersonateColumns(columns), k8s.ReadyGenerate(k8s.ImpersonateGenerate(gen))))
+}
+
 func registerTables(server *osquery.ExtensionManagerServer) {
 	server.RegisterPlugin(
 		// Admission Registration
-		table.NewPlugin("kubernetes_mutating_webhooks", admissionregistration.MutatingWebhookColumns(), admissionregistration.MutatingWebhooksGenerate),
-		table.NewPlugin("kubernetes_validating_webhooks", admissionregistration.ValidatingWebhookColumns(), admissionregistration.ValidatingWebhooksGenerate),
+		newPlugin("kubernetes_mutating_webhooks", admissionregistration.MutatingWebhookColumns(), admissionregistration.MutatingWebhooksGenerate),
+		newPlugin("kubernetes_validating_webhooks", admissionregistration.ValidatingWebhookColumns(), admissionregistration.ValidatingWebhooksGenerate),
+		newPlugin("kubernetes_webhook_risks", admissionregistration.WebhookRiskColumns(), admissionregistration.WebhookRisksGenerate),
 
 		// Apps
-		table.NewPlugin("kubernetes_daemon_sets", apps.DaemonSetColumns(), apps.DaemonSetsGenerate),
-		table.NewPlugin("kubernetes_daemon_set_containers", apps.DaemonSetContainerColumns(), apps.DaemonSetContainersGenerate),
-		table.NewPlugin("kubernetes_daemon_set_volumes", apps.DaemonSetVolumeColumns(), apps.DaemonSetVolumesGenerate),
-		table.NewPlugin("kubernetes_deployments", apps.DeploymentColumns(), apps.DeploymentsGenerate),
-		table.NewPlugin("kubernetes_deployments_containers", apps.DeploymentContainerColumns(), apps.DeploymentContainersGenerate),
-		table.NewPlugin("kubernetes_deployments_volumes", apps.DeploymentVolumeColumns(), apps.DeploymentVolumesGenerate),
-		table.NewPlugin("kubernetes_replica_sets", apps.ReplicaSetColumns(), apps.ReplicaSetsGenerate),
-		table.NewPlugin("kubernetes_replica_set_containers", apps.ReplicaSetContainerColumns(), apps.ReplicaSetContainersGenerate),
-		table.NewPlugin("kubernetes_replica_set_volumes", apps.ReplicaSetVolumeColumns(), apps.ReplicaSetVolumesGenerate),
-		table.NewPlugin("kubernetes_stateful_sets", apps.StatefulSetColumns(), apps.StatefulSetsGenerate),
-		table.NewPlugin("kubernetes_stateful_set_containers", apps.StatefulSetContainerColumns(), apps.StatefulSetContainersGenerate),
-		table.NewPlugin("kubernetes_stateful_set_volumes", apps.StatefulSetVolumeColumns(), apps.StatefulSetVolumesGenerate),
+		newPlugin("kubernetes_daemon_sets", apps.DaemonSetColumns(), apps.DaemonSetsGenerate),
+		newPlugin("kubernetes_daemon_set_containers", apps.DaemonSetContainerColumns(), apps.DaemonSetContainersGenerate),
+		newPlugin("kubernetes_daemon_set_volumes", apps.DaemonSetVolumeColumns(), apps.DaemonSetVolumesGenerate),
+		newPlugin("kubernetes_deployments", apps.DeploymentColumns(), apps.DeploymentsGenerate),
+		newPlugin("kubernetes_deployments_containers", apps.DeploymentContainerColumns(), apps.DeploymentContainersGenerate),
+		newPlugin("kubernetes_deployments_volumes", apps.DeploymentVolumeColumns(), apps.DeploymentVolumesGenerate),
+		newPlugin("kubernetes_replica_sets", apps.ReplicaSetColumns(), apps.ReplicaSetsGenerate),
+		newPlugin("kubernetes_replica_set_containers", apps.ReplicaSetContainerColumns(), apps.ReplicaSetContainersGenerate),
+		newPlugin("kubernetes_replica_set_volumes", apps.ReplicaSetVolumeColumns(), apps.ReplicaSetVolumesGenerate),
+		newPlugin("kubernetes_stateful_sets", apps.StatefulSetColumns(), apps.StatefulSetsGenerate),
+		newPlugin("kubernetes_stateful_set_containers", apps.StatefulSetContainerColumns(), apps.StatefulSetContainersGenerate),
+		newPlugin("kubernetes_stateful_set_volumes", apps.StatefulSetVolumeColumns(), apps.StatefulSetVolumesGenerate),
 
 		// Autoscaling
-		table.NewPlugin("kubernetes_horizontal_pod_autoscalers", autoscaling.HorizontalPodAutoscalersColumns(), autoscaling.HorizontalPodAutoscalerGenerate),
+		newPlugin("kubernetes_horizontal_pod_autoscalers", autoscaling.HorizontalPodAutoscalersColumns(), autoscaling.HorizontalPodAutoscalerGenerate),
 
 		// Batch
-		table.NewPlugin("kubernetes_cron_jobs", batch.CronJobColumns(), batch.CronJobsGenerate),
-		table.NewPlugin("kubernetes_jobs", batch.JobColumns(), batch.JobsGenerate),
+		newPlugin("kubernetes_cron_jobs", batch.CronJobColumns(), batch.CronJobsGenerate),
+		newPlugin("kubernetes_jobs", batch.JobColumns(), batch.JobsGenerate),
+
+		// CIS
+		newPlugin("kubernetes_cis_checks", cis.CISCheckColumns(), cis.CISChecksGenerate),
 
 		// Core
-		table.NewPlugin("kubernetes_config_maps", core.ConfigMapColumns(), core.ConfigMapsGenerate),
-		table.NewPlugin("kubernetes_endpoint_subsets", core.EndpointSubsetColumns(), core.EndpointSubsetsGenerate),
-		table.NewPlugin("kubernetes_limit_ranges", core.LimitRangeColumns(), core.LimitRangesGenerate),
-		table.NewPlugin("kubernetes_namespaces", core.NamespaceColumns(), core.NamespacesGenerate),
-		table.NewPlugin("kubernetes_nodes", core.NodeColumns(), core.NodesGenerate),
-		table.NewPlugin("kubernetes_persistent_volume_claims", core.PersistentVolumeClaimColumns(), core.PersistentVolumeClaimsGenerate),
-		table.NewPlugin("kubernetes_persistent_volumes", core.PersistentVolumeColumns(), core.PersistentVolumesGenerate),
-		table.NewPlugin("kubernetes_pod_templates", core.PodTemplateColumns(), core.PodTemplatesGenerate),
-		table.NewPlugin("kubernetes_pod_template_containers", core.PodTemplateContainerColumns(), core.PodTemplateContainersGenerate),
-		table.NewPlugin("kubernetes_pod_templates_volumes", core.PodTemplateVolumeColumns(), core.PodTemplateVolumesGenerate),
-		table.NewPlugin("kubernetes_pods", core.PodColumns(), core.PodsGenerate),
-		table.NewPlugin("kubernetes_pod_containers", core.PodContainerColumns(), core.PodContainersGenerate),
-		table.NewPlugin("kubernetes_pod_volumes", core.PodVolumeColumns(), core.PodVolumesGenerate),
-		table.NewPlugin("kubernetes_resource_quotas", core.ResourceQuotaColumns(), core.ResourceQuotasGenerate),
-		table.NewPlugin("kubernetes_secrets", core.SecretColumns(), core.SecretsGenerate),
-		table.NewPlugin("kubernetes_service_accounts", core.ServiceAccountColumns(), core.ServiceAccountsGenerate),
-		table.NewPlugin("kubernetes_services", core.ServiceColumns(), core.ServicesGenerate),
+		newPlugin("kubernetes_config_maps", core.ConfigMapColumns(), core.ConfigMapsGenerate),
+		newPlugin("kubernetes_endpoint_subsets", core.EndpointSubsetColumns(), core.EndpointSubsetsGenerate),
+		newPlugin("kubernetes_images", core.ImageColumns(), core.ImagesGenerate),
+		newPlugin("kubernetes_limit_ranges", core.LimitRangeColumns(), core.LimitRangesGenerate),
+		newPlugin("kubernetes_namespaces", core.NamespaceColumns(), core.NamespacesGenerate),
+		newPlugin("kubernetes_namespace_summary", core.NamespaceSummaryColumns(), core.NamespaceSummariesGenerate),
+		newPlugin("kubernetes_nodes", core.NodeColumns(), core.NodesGenerate),
+		newPlugin("kubernetes_node_images", core.NodeImageColumns(), core.NodeImagesGenerate),
+		newPlugin("kubernetes_node_conditions", core.NodeConditionColumns(), core.NodeConditionsGenerate),
+		newPlugin("kubernetes_node_addresses", core.NodeAddressColumns(), core.NodeAddressesGenerate),
+		newPlugin("kubernetes_node_allocation", core.NodeAllocationColumns(), core.NodeAllocationsGenerate),
+		newPlugin("kubernetes_persistent_volume_claims", core.PersistentVolumeClaimColumns(), core.PersistentVolumeClaimsGenerate),
+		newPlugin("kubernetes_persistent_volumes", core.PersistentVolumeColumns(), core.PersistentVolumesGenerate),
+		newPlugin("kubernetes_pod_templates", core.PodTemplateColumns(), core.PodTemplatesGenerate),
+		newPlugin("kubernetes_pod_template_containers", core.PodTemplateContainerColumns(), core.PodTemplateContainersGenerate),
+		newPlugin("kubernetes_pod_templates_volumes", core.PodTemplateVolumeColumns(), core.PodTemplateVolumesGenerate),
+		newPlugin("kubernetes_pods", core.PodColumns(), core.PodsGenerate),
+		newPlugin("kubernetes_pod_containers", core.PodContainerColumns(), core.PodContainersGenerate),
+		newPlugin("kubernetes_pod_volumes", core.PodVolumeColumns(), core.PodVolumesGenerate),
+		newPlugin("kubernetes_resource_quotas", core.ResourceQuotaColumns(), core.ResourceQuotasGenerate),
+		newPlugin("kubernetes_secrets", core.SecretColumns(), core.SecretsGenerate),
+		newPlugin("kubernetes_secret_keys", core.SecretKeyColumns(), core.SecretKeysGenerate),
+		newPlugin("kubernetes_service_accounts", core.ServiceAccountColumns(), core.ServiceAccountsGenerate),
+		newPlugin("kubernetes_service_account_usage", core.ServiceAccountUsageColumns(), core.ServiceAccountUsageGenerate),
+		newPlugin("kubernetes_services", core.ServiceColumns(), core.ServicesGenerate),
+		newPlugin("kubernetes_service_ports", core.ServicePortColumns(), core.ServicePortsGenerate),
+		newPlugin("kubernetes_service_pods", core.ServicePodColumns(), core.ServicePodsGenerate),
 
 		// Discovery
-		table.NewPlugin("kubernetes_api_resources", discovery.APIResourceColumns(), discovery.APIResourcesGenerate),
-		table.NewPlugin("kubernetes_info", discovery.InfoColumns(), discovery.InfoGenerate),
+		newPlugin("kubernetes_api_resources", discovery.APIResourceColumns(), discovery.APIResourcesGenerate),
+		newPlugin("kubernetes_endpoint_slices", discovery.EndpointSliceColumns(), discovery.EndpointSlicesGenerate),
+		newPlugin("kubernetes_endpoint_slice_endpoints", discovery.EndpointSliceEndpointColumns(), discovery.EndpointSliceEndpointsGenerate),
+		newPlugin("kubernetes_info", discovery.InfoColumns(), discovery.InfoGenerate),
+
+		// Metrics
+		newPlugin("kubernetes_node_metrics", metrics.NodeMetricsColumns(), metrics.NodeMetricsGenerate),
+		newPlugin("kubernetes_pod_metrics", metrics.PodMetricsColumns(), metrics.PodMetricsGenerate),
+		newPlugin("kubernetes_resource_recommendations", metrics.ResourceRecommendationColumns(), metrics.ResourceRecommendationsGenerate),
 
 		// Networking
-		table.NewPlugin("kubernetes_ingress_classes", networking.IngressClassColumns(), networking.IngressClassesGenerate),
-		table.NewPlugin("kubernetes_ingresses", networking.IngressColumns(), networking.IngressesGenerate),
-		table.NewPlugin("kubernetes_network_policies", networking.NetworkPolicyColumns(), networking.NetworkPoliciesGenerate),
+		newPlugin("kubernetes_ingress_classes", networking.IngressClassColumns(), networking.IngressClassesGenerate),
+		newPlugin("kubernetes_ingresses", networking.IngressColumns(), networking.IngressesGenerate),
+		newPlugin("kubernetes_ingress_rules", networking.IngressRuleColumns(), networking.IngressRulesGenerate),
+		newPlugin("kubernetes_ingress_tls", networking.IngressTLSColumns(), networking.IngressTLSGenerate),
+		newPlugin("kubernetes_network_policies", networking.NetworkPolicyColumns(), networking.NetworkPoliciesGenerate),
+		newPlugin("kubernetes_network_policy_rules", networking.NetworkPolicyRuleColumns(), networking.NetworkPolicyRulesGenerate),
+		newPlugin("kubernetes_pod_network_isolation", networking.PodNetworkIsolationColumns(), networking.PodNetworkIsolationGenerate),
+		newPlugin("kubernetes_network_reachability", networking.NetworkReachabilityColumns(), networking.NetworkReachabilityGenerate),
 
 		// Policy
-		table.NewPlugin("kubernetes_pod_disruption_budget", policy.PodDisruptionBudgetColumns(), policy.PodDisruptionBudgetsGenerate),
-		table.NewPlugin("kubernetes_pod_security_policies", policy.PodSecurityPolicyColumns(), policy.PodSecurityPoliciesGenerate),
+		newPlugin("kubernetes_pod_disruption_budget", policy.PodDisruptionBudgetColumns(), policy.PodDisruptionBudgetsGenerate),
+		newPlugin("kubernetes_pod_security_policies", policy.PodSecurityPolicyColumns(), policy.PodSecurityPoliciesGenerate),
 
 		// RBAC
-		table.NewPlugin("kubernetes_cluster_role_binding_subjects", rbac.ClusterRoleBindingSubjectColumns(), rbac.ClusterRoleBindingSubjectsGenerate),
-		table.NewPlugin("kubernetes_cluster_role_policy_rule", rbac.ClusterRolePolicyRuleColumns(), rbac.ClusterRolePolicyRulesGenerate),
-		table.NewPlugin("kubernetes_role_binding_subjects", rbac.RoleBindingSubjectColumns(), rbac.RoleBindingSubjectsGenerate),
-		table.NewPlugin("kubernetes_role_policy_rule", rbac.RolePolicyRuleColumns(), rbac.RolePolicyRulesGenerate),
+		newPlugin("kubernetes_cluster_role_binding_subjects", rbac.ClusterRoleBindingSubjectColumns(), rbac.ClusterRoleBindingSubjectsGenerate),
+		newPlugin("kubernetes_cluster_role_policy_rule", rbac.ClusterRolePolicyRuleColumns(), rbac.ClusterRolePolicyRulesGenerate),
+		newPlugin("kubernetes_role_binding_subjects", rbac.RoleBindingSubjectColumns(), rbac.RoleBindingSubjectsGenerate),
+		newPlugin("kubernetes_role_policy_rule", rbac.RolePolicyRuleColumns(), rbac.RolePolicyRulesGenerate),
+		newPlugin("kubernetes_rbac_permissions", rbac.RBACPermissionColumns(), rbac.RBACPermissionsGenerate),
+		newPlugin("kubernetes_rbac_risks", rbac.RBACRiskColumns(), rbac.RBACRisksGenerate),
+		newPlugin("kubernetes_who_can", rbac.WhoCanColumns(), rbac.WhoCanGenerate),
+
+		// Security
+		newPlugin("kubernetes_certificates", security.CertificateColumns(), security.CertificatesGenerate),
+		newPlugin("kubernetes_pod_security_violations", security.PodSecurityViolationColumns(), security.PodSecurityViolationsGenerate),
 
 		// Storage
-		table.NewPlugin("kubernetes_csi_drivers", storage.CSIDriverColumns(), storage.CSIDriversGenerate),
-		table.NewPlugin("kubernetes_csi_node_drivers", storage.CSINodeDriverColumns(), storage.CSINodeDriversGenerate),
-		table.NewPlugin("kubernetes_storage_capacities", storage.CSIStorageCapacityColumns(), storage.CSIStorageCapacitiesGenerate),
-		table.NewPlugin("kubernetes_storage_classes", storage.SGClassColumns(), storage.SGClassesGenerate),
-		table.NewPlugin("kubernetes_volume_attachments", storage.VolumeAttachmentColumns(), storage.VolumeAttachmentsGenerate),
+		newPlugin("kubernetes_csi_drivers", storage.CSIDriverColumns(), storage.CSIDriversGenerate),
+		newPlugin("kubernetes_csi_node_drivers", storage.CSINodeDriverColumns(), storage.CSINodeDriversGenerate),
+		newPlugin("kubernetes_storage_capacities", storage.CSIStorageCapacityColumns(), storage.CSIStorageCapacitiesGenerate),
+		newPlugin("kubernetes_storage_classes", storage.SGClassColumns(), storage.SGClassesGenerate),
+		newPlugin("kubernetes_volume_attachments", storage.VolumeAttachmentColumns(), storage.VolumeAttachmentsGenerate),
 	)
 }
 
@@ -116,11 +174,38 @@ func main() {
 		panic("Missing required --socket argument")
 	}
 
-	err := k8s.Init()
+	opts := k8s.Options{
+		ClusterUID:       *clusterUID,
+		ClusterName:      *clusterName,
+		ClusterUIDSource: *clusterUIDSource,
+		ImpersonateUser:  *as,
+	}
+	if *asGroup != "" {
+		opts.ImpersonateGroups = strings.Split(*asGroup, ",")
+	}
+	if *impersonateAllow != "" {
+		opts.ImpersonateAllowedUsers = strings.Split(*impersonateAllow, ",")
+	}
+
+	if *secretSalt != "" {
+		core.SetSecretKeySalt(*secretSalt)
+	}
+	if *allowedRegistries != "" {
+		core.SetAllowedRegistries(strings.Split(*allowedRegistries, ","))
+	}
+
+	err := k8s.Init(opts)
 	if err != nil {
 		panic(err.Error())
 	}
 
+	if *metricsInterval > 0 {
+		err = metrics.StartSampler(time.Second*time.Duration(*metricsInterval), time.Hour*time.Duration(*metricsRetention))
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+
 	// TODO: Version and SDK version
 	server, err := osquery.NewExtensionManagerServer(
 		"kubequery",
@@ -1,12 +1,14 @@
 module github.com/Uptycs/kubequery
 
-go 1.15
+go 1.16
 
 require (
 	github.com/iancoleman/strcase v0.1.3
 	github.com/kolide/osquery-go v0.0.0-20200604192029-b019be7063ac
 	github.com/stretchr/testify v1.7.0
-	k8s.io/api v0.20.2
-	k8s.io/apimachinery v0.20.2
-	k8s.io/client-go v0.20.0
+	k8s.io/api v0.21.1
+	k8s.io/apimachinery v0.21.1
+	k8s.io/client-go v0.21.1
+	k8s.io/kube-aggregator v0.21.1
+	k8s.io/metrics v0.21.1
 )
@@ -19,7 +19,18 @@ import (
 )
 
 type mutatingWebhook struct {
-	v1.MutatingWebhook
+	k8s.CommonFields
+	ClientConfigFields
+	WebhookName             string
+	Rules                   []v1.RuleWithOperations
+	FailurePolicy           *v1.FailurePolicyType
+	MatchPolicy             *v1.MatchPolicyType
+	NamespaceSelector       *metav1.LabelSelector
+	ObjectSelector          *metav1.LabelSelector
+	SideEffects             *v1.SideEffectClass
+	TimeoutSeconds          *int32
+	AdmissionReviewVersions []string
+	ReinvocationPolicy      *v1.ReinvocationPolicyType
 }
 
 // MutatingWebhookColumns returns kubernetes mutating webhook fields as Osquery table columns.
@@ -33,7 +44,7 @@ func MutatingWebhooksGenerate(ctx context.Context, queryContext table.QueryConte
 	results := make([]map[string]string, 0)
 
 	for {
-		mwcs, err := k8s.GetClient().AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), options)
+		mwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -41,7 +52,18 @@ func MutatingWebhooksGenerate(ctx context.Context, queryContext table.QueryConte
 		for _, mwc := range mwcs.Items {
 			for _, mw := range mwc.Webhooks {
 				item := &mutatingWebhook{
-					MutatingWebhook: mw,
+					CommonFields:            k8s.GetCommonFields(mwc.ObjectMeta),
+					ClientConfigFields:      getClientConfigFields(mw.ClientConfig),
+					WebhookName:             mw.Name,
+					Rules:                   mw.Rules,
+					FailurePolicy:           mw.FailurePolicy,
+					MatchPolicy:             mw.MatchPolicy,
+					NamespaceSelector:       mw.NamespaceSelector,
+					ObjectSelector:          mw.ObjectSelector,
+					SideEffects:             mw.SideEffects,
+					TimeoutSeconds:          mw.TimeoutSeconds,
+					AdmissionReviewVersions: mw.AdmissionReviewVersions,
+					ReinvocationPolicy:      mw.ReinvocationPolicy,
 				}
 				results = append(results, k8s.ToMap(item))
 			}
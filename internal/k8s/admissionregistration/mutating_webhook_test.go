@@ -17,6 +17,7 @@ import (
 	"github.com/kolide/osquery-go/plugin/table"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -24,7 +25,13 @@ import (
 func TestMutatingWebhooksGenerate(t *testing.T) {
 	i32 := int32(123)
 	url := string("https://www.google.com")
+	port := int32(8443)
 	k8s.SetClient(fake.NewSimpleClientset(&v1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "mwc1",
+			UID:    types.UID("m123"),
+			Labels: map[string]string{"a": "b"},
+		},
 		Webhooks: []v1.MutatingWebhook{
 			{
 				Name:           "mw1",
@@ -34,23 +41,39 @@ func TestMutatingWebhooksGenerate(t *testing.T) {
 			{
 				Name:           "mw2",
 				TimeoutSeconds: &i32,
-				ClientConfig:   v1.WebhookClientConfig{URL: &url},
+				ClientConfig: v1.WebhookClientConfig{
+					Service:  &v1.ServiceReference{Namespace: "n1", Name: "s1", Port: &port},
+					CABundle: []byte("ca"),
+				},
 			},
 		},
-	}), types.UID(""))
+	}), types.UID("c123"))
 
 	mws, err := MutatingWebhooksGenerate(context.TODO(), table.QueryContext{})
 	assert.Nil(t, err)
 	assert.Equal(t, []map[string]string{
 		{
-			"name":            "mw1",
-			"timeout_seconds": "123",
-			"client_config":   "{\"url\":\"https://www.google.com\"}",
+			"uid":                "m123",
+			"cluster_uid":        "c123",
+			"name":               "mwc1",
+			"creation_timestamp": "0",
+			"labels":             "{\"a\":\"b\"}",
+			"webhook_name":       "mw1",
+			"timeout_seconds":    "123",
+			"client_config_url":  "https://www.google.com",
 		},
 		{
-			"name":            "mw2",
-			"timeout_seconds": "123",
-			"client_config":   "{\"url\":\"https://www.google.com\"}",
+			"uid":                             "m123",
+			"cluster_uid":                     "c123",
+			"name":                            "mwc1",
+			"creation_timestamp":              "0",
+			"labels":                          "{\"a\":\"b\"}",
+			"webhook_name":                    "mw2",
+			"timeout_seconds":                 "123",
+			"client_config_service_namespace": "n1",
+			"client_config_service_name":      "s1",
+			"client_config_service_port":      "8443",
+			"ca_bundle_fingerprint":           "6959097001d10501ac7d54c0bdb8db61420f658f2922cc26e46d536119a31126",
 		},
 	}, mws)
 }
@@ -19,7 +19,17 @@ import (
 )
 
 type validatingWebhook struct {
-	v1.ValidatingWebhook
+	k8s.CommonFields
+	ClientConfigFields
+	WebhookName             string
+	Rules                   []v1.RuleWithOperations
+	FailurePolicy           *v1.FailurePolicyType
+	MatchPolicy             *v1.MatchPolicyType
+	NamespaceSelector       *metav1.LabelSelector
+	ObjectSelector          *metav1.LabelSelector
+	SideEffects             *v1.SideEffectClass
+	TimeoutSeconds          *int32
+	AdmissionReviewVersions []string
 }
 
 // ValidatingWebhookColumns returns kubernetes validating webhook fields as Osquery table columns.
@@ -33,7 +43,7 @@ func ValidatingWebhooksGenerate(ctx context.Context, queryContext table.QueryCon
 	results := make([]map[string]string, 0)
 
 	for {
-		vwcs, err := k8s.GetClient().AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), options)
+		vwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -41,7 +51,17 @@ func ValidatingWebhooksGenerate(ctx context.Context, queryContext table.QueryCon
 		for _, vwc := range vwcs.Items {
 			for _, vw := range vwc.Webhooks {
 				item := &validatingWebhook{
-					ValidatingWebhook: vw,
+					CommonFields:            k8s.GetCommonFields(vwc.ObjectMeta),
+					ClientConfigFields:      getClientConfigFields(vw.ClientConfig),
+					WebhookName:             vw.Name,
+					Rules:                   vw.Rules,
+					FailurePolicy:           vw.FailurePolicy,
+					MatchPolicy:             vw.MatchPolicy,
+					NamespaceSelector:       vw.NamespaceSelector,
+					ObjectSelector:          vw.ObjectSelector,
+					SideEffects:             vw.SideEffects,
+					TimeoutSeconds:          vw.TimeoutSeconds,
+					AdmissionReviewVersions: vw.AdmissionReviewVersions,
 				}
 				results = append(results, k8s.ToMap(item))
 			}
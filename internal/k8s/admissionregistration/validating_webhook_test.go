@@ -17,6 +17,7 @@ import (
 	"github.com/kolide/osquery-go/plugin/table"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -24,7 +25,13 @@ import (
 func TestValidatingWebhooksGenerate(t *testing.T) {
 	i32 := int32(123)
 	url := string("https://www.google.com")
+	port := int32(8443)
 	k8s.SetClient(fake.NewSimpleClientset(&v1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "vwc1",
+			UID:    types.UID("v123"),
+			Labels: map[string]string{"a": "b"},
+		},
 		Webhooks: []v1.ValidatingWebhook{
 			{
 				Name:           "vw1",
@@ -34,23 +41,39 @@ func TestValidatingWebhooksGenerate(t *testing.T) {
 			{
 				Name:           "vw2",
 				TimeoutSeconds: &i32,
-				ClientConfig:   v1.WebhookClientConfig{URL: &url},
+				ClientConfig: v1.WebhookClientConfig{
+					Service:  &v1.ServiceReference{Namespace: "n1", Name: "s1", Port: &port},
+					CABundle: []byte("ca"),
+				},
 			},
 		},
-	}), types.UID(""))
+	}), types.UID("c123"))
 
 	mws, err := ValidatingWebhooksGenerate(context.TODO(), table.QueryContext{})
 	assert.Nil(t, err)
 	assert.Equal(t, []map[string]string{
 		{
-			"name":            "vw1",
-			"timeout_seconds": "123",
-			"client_config":   "{\"url\":\"https://www.google.com\"}",
+			"uid":                "v123",
+			"cluster_uid":        "c123",
+			"name":               "vwc1",
+			"creation_timestamp": "0",
+			"labels":             "{\"a\":\"b\"}",
+			"webhook_name":       "vw1",
+			"timeout_seconds":    "123",
+			"client_config_url":  "https://www.google.com",
 		},
 		{
-			"name":            "vw2",
-			"timeout_seconds": "123",
-			"client_config":   "{\"url\":\"https://www.google.com\"}",
+			"uid":                             "v123",
+			"cluster_uid":                     "c123",
+			"name":                            "vwc1",
+			"creation_timestamp":              "0",
+			"labels":                          "{\"a\":\"b\"}",
+			"webhook_name":                    "vw2",
+			"timeout_seconds":                 "123",
+			"client_config_service_namespace": "n1",
+			"client_config_service_name":      "s1",
+			"client_config_service_port":      "8443",
+			"ca_bundle_fingerprint":           "6959097001d10501ac7d54c0bdb8db61420f658f2922cc26e46d536119a31126",
 		},
 	}, mws)
 }
@@ -0,0 +1,45 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package admissionregistration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	v1 "k8s.io/api/admissionregistration/v1"
+)
+
+// ClientConfigFields contains webhook client configuration as a flat structure.
+// CA bundle is replaced by its SHA-256 fingerprint.
+type ClientConfigFields struct {
+	ClientConfigURL              *string
+	ClientConfigServiceNamespace string
+	ClientConfigServiceName      string
+	ClientConfigServicePath      *string
+	ClientConfigServicePort      *int32
+	CABundleFingerprint          string
+}
+
+func getClientConfigFields(cc v1.WebhookClientConfig) ClientConfigFields {
+	item := ClientConfigFields{
+		ClientConfigURL: cc.URL,
+	}
+	if cc.Service != nil {
+		item.ClientConfigServiceNamespace = cc.Service.Namespace
+		item.ClientConfigServiceName = cc.Service.Name
+		item.ClientConfigServicePath = cc.Service.Path
+		item.ClientConfigServicePort = cc.Service.Port
+	}
+	if len(cc.CABundle) > 0 {
+		sum := sha256.Sum256(cc.CABundle)
+		item.CABundleFingerprint = hex.EncodeToString(sum[:])
+	}
+	return item
+}
@@ -0,0 +1,328 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package admissionregistration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Webhook risk severities.
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+)
+
+// API server gives up on a webhook call after 30 seconds. Timeouts at or above this value are flagged.
+const timeoutWarningSeconds = 25
+
+// clusterScopedResources are the built-in cluster scoped resources. Requests for them are never in kube-system.
+// Namespaces are not included, because namespace selector is also matched against namespace objects.
+var clusterScopedResources = map[string]bool{
+	"apiservices":                     true,
+	"certificatesigningrequests":      true,
+	"clusterrolebindings":             true,
+	"clusterroles":                    true,
+	"csidrivers":                      true,
+	"csinodes":                        true,
+	"customresourcedefinitions":       true,
+	"ingressclasses":                  true,
+	"mutatingwebhookconfigurations":   true,
+	"nodes":                           true,
+	"persistentvolumes":               true,
+	"podsecuritypolicies":             true,
+	"priorityclasses":                 true,
+	"runtimeclasses":                  true,
+	"storageclasses":                  true,
+	"validatingwebhookconfigurations": true,
+	"volumeattachments":               true,
+}
+
+// webhook contains the fields of mutating and validating webhooks that are relevant for risk analysis.
+type webhook struct {
+	configKind        string
+	config            metav1.ObjectMeta
+	name              string
+	clientConfig      v1.WebhookClientConfig
+	rules             []v1.RuleWithOperations
+	failurePolicy     *v1.FailurePolicyType
+	namespaceSelector *metav1.LabelSelector
+	sideEffects       *v1.SideEffectClass
+	timeoutSeconds    *int32
+}
+
+type webhookRisk struct {
+	ClusterUID        types.UID
+	ConfigurationKind string
+	ConfigurationUID  types.UID
+	ConfigurationName string
+	WebhookName       string
+	RuleID            string
+	Severity          string
+	Explanation       string
+}
+
+// WebhookRiskColumns returns admission webhook risk fields as Osquery table columns.
+func WebhookRiskColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&webhookRisk{})
+}
+
+func listWebhooks(ctx context.Context) ([]webhook, error) {
+	options := metav1.ListOptions{}
+	webhooks := make([]webhook, 0)
+
+	for {
+		mwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mwc := range mwcs.Items {
+			for _, mw := range mwc.Webhooks {
+				webhooks = append(webhooks, webhook{
+					configKind:        "MutatingWebhookConfiguration",
+					config:            mwc.ObjectMeta,
+					name:              mw.Name,
+					clientConfig:      mw.ClientConfig,
+					rules:             mw.Rules,
+					failurePolicy:     mw.FailurePolicy,
+					namespaceSelector: mw.NamespaceSelector,
+					sideEffects:       mw.SideEffects,
+					timeoutSeconds:    mw.TimeoutSeconds,
+				})
+			}
+		}
+
+		if mwcs.Continue == "" {
+			break
+		}
+		options.Continue = mwcs.Continue
+	}
+
+	options = metav1.ListOptions{}
+	for {
+		vwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vwc := range vwcs.Items {
+			for _, vw := range vwc.Webhooks {
+				webhooks = append(webhooks, webhook{
+					configKind:        "ValidatingWebhookConfiguration",
+					config:            vwc.ObjectMeta,
+					name:              vw.Name,
+					clientConfig:      vw.ClientConfig,
+					rules:             vw.Rules,
+					failurePolicy:     vw.FailurePolicy,
+					namespaceSelector: vw.NamespaceSelector,
+					sideEffects:       vw.SideEffects,
+					timeoutSeconds:    vw.TimeoutSeconds,
+				})
+			}
+		}
+
+		if vwcs.Continue == "" {
+			break
+		}
+		options.Continue = vwcs.Continue
+	}
+
+	return webhooks, nil
+}
+
+// failClosed returns true if the webhook rejects requests when it can not be called. Fail is the default in v1.
+func (w webhook) failClosed() bool {
+	return w.failurePolicy == nil || *w.failurePolicy == v1.Fail
+}
+
+// severity returns high for webhooks that fail closed and medium otherwise.
+func (w webhook) severity() string {
+	if w.failClosed() {
+		return severityHigh
+	}
+	return severityMedium
+}
+
+func (w webhook) interceptsResource(group, resource string) bool {
+	for _, r := range w.rules {
+		groupMatch := false
+		for _, g := range r.APIGroups {
+			if g == "*" || g == group {
+				groupMatch = true
+				break
+			}
+		}
+		if !groupMatch {
+			continue
+		}
+		for _, res := range r.Resources {
+			if res == "*" || res == "*/*" || res == resource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interceptsNamespacedResources returns true if any rule can match resources in a namespace. Rules with
+// Cluster scope and rules listing only built-in cluster scoped resources never do.
+func (w webhook) interceptsNamespacedResources() bool {
+	for _, r := range w.rules {
+		if r.Scope != nil && *r.Scope == v1.ClusterScope {
+			continue
+		}
+		for _, res := range r.Resources {
+			if !clusterScopedResources[strings.SplitN(res, "/", 2)[0]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w webhook) interceptsNamespace(ns map[string]string) bool {
+	if w.namespaceSelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(w.namespaceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(ns))
+}
+
+func readyEndpoints(ctx context.Context, namespace, name string) (int, error) {
+	ep, err := k8s.GetClient(ctx).CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	count := 0
+	for _, s := range ep.Subsets {
+		count += len(s.Addresses)
+	}
+	return count, nil
+}
+
+func kubeSystemLabels(ctx context.Context) (map[string]string, error) {
+	// Namespaces are labelled with their name since kubernetes 1.21. Assume the same for older clusters.
+	result := map[string]string{"kubernetes.io/metadata.name": metav1.NamespaceSystem}
+
+	ns, err := k8s.GetClient(ctx).CoreV1().Namespaces().Get(context.TODO(), metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+	for k, v := range ns.Labels {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (w webhook) risk(ruleID, severity, explanation string) *webhookRisk {
+	return &webhookRisk{
+		ClusterUID:        k8s.GetClusterUID(),
+		ConfigurationKind: w.configKind,
+		ConfigurationUID:  w.config.UID,
+		ConfigurationName: w.config.Name,
+		WebhookName:       w.name,
+		RuleID:            ruleID,
+		Severity:          severity,
+		Explanation:       explanation,
+	}
+}
+
+func evaluateWebhook(ctx context.Context, w webhook, ksLabels map[string]string) ([]*webhookRisk, error) {
+	risks := make([]*webhookRisk, 0)
+
+	if svc := w.clientConfig.Service; svc != nil && w.failClosed() {
+		count, err := readyEndpoints(ctx, svc.Namespace, svc.Name)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			risks = append(risks, w.risk("fail-closed-without-endpoints", severityHigh,
+				fmt.Sprintf("Failure policy is Fail and service %s/%s has no ready endpoints. All matching requests are rejected", svc.Namespace, svc.Name)))
+		}
+	}
+
+	if w.interceptsNamespacedResources() && w.interceptsNamespace(ksLabels) {
+		risks = append(risks, w.risk("intercepts-kube-system", w.severity(),
+			"Rules and namespace selector match resources in kube-system namespace. Webhook outage can block control plane components and deadlock the cluster"))
+	}
+
+	if w.interceptsResource("coordination.k8s.io", "leases") {
+		risks = append(risks, w.risk("intercepts-leases", w.severity(),
+			"Rules match leases. Webhook outage can block leader election and node heartbeats"))
+	}
+
+	if w.timeoutSeconds != nil && *w.timeoutSeconds >= timeoutWarningSeconds {
+		risks = append(risks, w.risk("timeout-near-limit", severityMedium,
+			fmt.Sprintf("Timeout of %d seconds is close to the 30 seconds API server request limit", *w.timeoutSeconds)))
+	}
+
+	if w.namespaceSelector == nil || (len(w.namespaceSelector.MatchLabels) == 0 && len(w.namespaceSelector.MatchExpressions) == 0) {
+		risks = append(risks, w.risk("missing-namespace-selector", severityLow,
+			"Namespace selector is not set. Webhook is called for requests in all namespaces"))
+	}
+
+	if w.sideEffects == nil || (*w.sideEffects != v1.SideEffectClassNone && *w.sideEffects != v1.SideEffectClassNoneOnDryRun) {
+		sideEffects := "unset"
+		if w.sideEffects != nil {
+			sideEffects = string(*w.sideEffects)
+		}
+		risks = append(risks, w.risk("side-effects", severityMedium,
+			fmt.Sprintf("Side effects is %s instead of None or NoneOnDryRun. Dry run requests matching the webhook are rejected", sideEffects)))
+	}
+
+	return risks, nil
+}
+
+// WebhookRisksGenerate generates risky admission webhook configurations as Osquery table data.
+func WebhookRisksGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	webhooks, err := listWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ksLabels, err := kubeSystemLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range webhooks {
+		risks, err := evaluateWebhook(ctx, w, ksLabels)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range risks {
+			results = append(results, k8s.ToMap(r))
+		}
+	}
+
+	return results, nil
+}
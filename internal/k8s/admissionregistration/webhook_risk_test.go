@@ -0,0 +1,146 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package admissionregistration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWebhookRisksGenerate(t *testing.T) {
+	fail := v1.Fail
+	ignore := v1.Ignore
+	none := v1.SideEffectClassNone
+	some := v1.SideEffectClassSome
+	timeout := int32(28)
+	cluster := v1.ClusterScope
+	url := "https://webhook.example.com"
+	k8s.SetClient(fake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Labels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "n1"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&v1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "mwc1", UID: types.UID("m123")},
+			Webhooks: []v1.MutatingWebhook{
+				{
+					Name:          "safe",
+					FailurePolicy: &fail,
+					SideEffects:   &none,
+					ClientConfig:  v1.WebhookClientConfig{Service: &v1.ServiceReference{Namespace: "n1", Name: "ready"}},
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "kubernetes.io/metadata.name", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"kube-system"}},
+						},
+					},
+				},
+				{
+					Name:           "risky",
+					SideEffects:    &some,
+					TimeoutSeconds: &timeout,
+					ClientConfig:   v1.WebhookClientConfig{Service: &v1.ServiceReference{Namespace: "n1", Name: "missing"}},
+					Rules: []v1.RuleWithOperations{
+						{Rule: v1.Rule{APIGroups: []string{"*"}, Resources: []string{"*"}}},
+					},
+				},
+			},
+		},
+		&v1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "vwc1", UID: types.UID("v123")},
+			Webhooks: []v1.ValidatingWebhook{
+				{
+					Name:              "ignored",
+					FailurePolicy:     &ignore,
+					SideEffects:       &none,
+					ClientConfig:      v1.WebhookClientConfig{Service: &v1.ServiceReference{Namespace: "n1", Name: "missing"}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Rules: []v1.RuleWithOperations{
+						{Rule: v1.Rule{APIGroups: []string{"coordination.k8s.io"}, Resources: []string{"leases"}}},
+					},
+				},
+				{
+					Name:          "cluster-scoped",
+					FailurePolicy: &fail,
+					SideEffects:   &none,
+					ClientConfig:  v1.WebhookClientConfig{URL: &url},
+					Rules: []v1.RuleWithOperations{
+						{Rule: v1.Rule{APIGroups: []string{""}, Resources: []string{"nodes", "nodes/status"}}},
+						{Rule: v1.Rule{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"*"}, Scope: &cluster}},
+					},
+				},
+			},
+		},
+	), types.UID("c123"))
+
+	risks, err := WebhookRisksGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+
+	risk := func(kind, uid, config, name, id, severity, explanation string) map[string]string {
+		return map[string]string{
+			"cluster_uid":        "c123",
+			"configuration_kind": kind,
+			"configuration_uid":  uid,
+			"configuration_name": config,
+			"webhook_name":       name,
+			"rule_id":            id,
+			"severity":           severity,
+			"explanation":        explanation,
+		}
+	}
+	assert.ElementsMatch(t, []map[string]string{
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "fail-closed-without-endpoints", "high",
+			"Failure policy is Fail and service n1/missing has no ready endpoints. All matching requests are rejected"),
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "intercepts-kube-system", "high",
+			"Rules and namespace selector match resources in kube-system namespace. Webhook outage can block control plane components and deadlock the cluster"),
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "intercepts-leases", "high",
+			"Rules match leases. Webhook outage can block leader election and node heartbeats"),
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "timeout-near-limit", "medium",
+			"Timeout of 28 seconds is close to the 30 seconds API server request limit"),
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "missing-namespace-selector", "low",
+			"Namespace selector is not set. Webhook is called for requests in all namespaces"),
+		risk("MutatingWebhookConfiguration", "m123", "mwc1", "risky", "side-effects", "medium",
+			"Side effects is Some instead of None or NoneOnDryRun. Dry run requests matching the webhook are rejected"),
+		risk("ValidatingWebhookConfiguration", "v123", "vwc1", "ignored", "intercepts-leases", "medium",
+			"Rules match leases. Webhook outage can block leader election and node heartbeats"),
+		risk("ValidatingWebhookConfiguration", "v123", "vwc1", "cluster-scoped", "missing-namespace-selector", "low",
+			"Namespace selector is not set. Webhook is called for requests in all namespaces"),
+	}, risks)
+}
+
+func TestInterceptsNamespacedResources(t *testing.T) {
+	cluster := v1.ClusterScope
+	namespaced := v1.NamespacedScope
+	rules := func(rules ...v1.Rule) webhook {
+		w := webhook{}
+		for _, r := range rules {
+			w.rules = append(w.rules, v1.RuleWithOperations{Rule: r})
+		}
+		return w
+	}
+
+	assert.False(t, rules().interceptsNamespacedResources())
+	assert.False(t, rules(v1.Rule{APIGroups: []string{""}, Resources: []string{"nodes", "persistentvolumes"}}).interceptsNamespacedResources())
+	assert.False(t, rules(v1.Rule{APIGroups: []string{"*"}, Resources: []string{"*"}, Scope: &cluster}).interceptsNamespacedResources())
+	assert.True(t, rules(v1.Rule{APIGroups: []string{"*"}, Resources: []string{"*"}}).interceptsNamespacedResources())
+	assert.True(t, rules(v1.Rule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Scope: &namespaced}).interceptsNamespacedResources())
+	assert.True(t, rules(v1.Rule{APIGroups: []string{""}, Resources: []string{"namespaces"}}).interceptsNamespacedResources())
+}
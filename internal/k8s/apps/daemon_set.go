@@ -39,7 +39,7 @@ func DaemonSetsGenerate(ctx context.Context, queryContext table.QueryContext) ([
 	results := make([]map[string]string, 0)
 
 	for {
-		dss, err := k8s.GetClient().AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
+		dss, err := k8s.GetClient(ctx).AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -66,6 +66,28 @@ func DaemonSetsGenerate(ctx context.Context, queryContext table.QueryContext) ([
 	return results, nil
 }
 
+// ListDaemonSets returns all kubernetes daemon sets in all namespaces.
+func ListDaemonSets(ctx context.Context) ([]v1.DaemonSet, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.DaemonSet, 0)
+
+	for {
+		dss, err := k8s.GetClient(ctx).AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, dss.Items...)
+
+		if dss.Continue == "" {
+			break
+		}
+		options.Continue = dss.Continue
+	}
+
+	return results, nil
+}
+
 type daemonSetContainer struct {
 	k8s.CommonNamespacedFields
 	k8s.CommonContainerFields
@@ -84,7 +106,7 @@ func DaemonSetContainersGenerate(ctx context.Context, queryContext table.QueryCo
 	results := make([]map[string]string, 0)
 
 	for {
-		dss, err := k8s.GetClient().AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
+		dss, err := k8s.GetClient(ctx).AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -148,7 +170,7 @@ func DaemonSetVolumesGenerate(ctx context.Context, queryContext table.QueryConte
 	results := make([]map[string]string, 0)
 
 	for {
-		dss, err := k8s.GetClient().AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
+		dss, err := k8s.GetClient(ctx).AppsV1().DaemonSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -143,6 +143,7 @@ func TestDaemonSetContainersGenerate(t *testing.T) {
 			"privileged":                 "1",
 			"readiness_probe":            "{\"exec\":{\"command\":[\"/bin/calico-node\",\"-felix-ready\"]},\"timeoutSeconds\":1,\"periodSeconds\":10,\"successThreshold\":1,\"failureThreshold\":3}",
 			"resources":                  "{\"requests\":{\"cpu\":\"250m\"}}",
+			"cpu_request_millicores":     "250",
 			"stdin":                      "0",
 			"stdin_once":                 "0",
 			"termination_message_path":   "/dev/termination-log",
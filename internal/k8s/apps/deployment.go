@@ -42,7 +42,7 @@ func DeploymentsGenerate(ctx context.Context, queryContext table.QueryContext) (
 	results := make([]map[string]string, 0)
 
 	for {
-		ds, err := k8s.GetClient().AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
+		ds, err := k8s.GetClient(ctx).AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -72,6 +72,28 @@ func DeploymentsGenerate(ctx context.Context, queryContext table.QueryContext) (
 	return results, nil
 }
 
+// ListDeployments returns all kubernetes deployments in all namespaces.
+func ListDeployments(ctx context.Context) ([]v1.Deployment, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Deployment, 0)
+
+	for {
+		ds, err := k8s.GetClient(ctx).AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, ds.Items...)
+
+		if ds.Continue == "" {
+			break
+		}
+		options.Continue = ds.Continue
+	}
+
+	return results, nil
+}
+
 type deploymentContainer struct {
 	k8s.CommonNamespacedFields
 	k8s.CommonContainerFields
@@ -90,7 +112,7 @@ func DeploymentContainersGenerate(ctx context.Context, queryContext table.QueryC
 	results := make([]map[string]string, 0)
 
 	for {
-		ds, err := k8s.GetClient().AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
+		ds, err := k8s.GetClient(ctx).AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -154,7 +176,7 @@ func DeploymentVolumesGenerate(ctx context.Context, queryContext table.QueryCont
 	results := make([]map[string]string, 0)
 
 	for {
-		ds, err := k8s.GetClient().AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
+		ds, err := k8s.GetClient(ctx).AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
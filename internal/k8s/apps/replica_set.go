@@ -38,7 +38,7 @@ func ReplicaSetsGenerate(ctx context.Context, queryContext table.QueryContext) (
 	results := make([]map[string]string, 0)
 
 	for {
-		rss, err := k8s.GetClient().AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
+		rss, err := k8s.GetClient(ctx).AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -64,6 +64,28 @@ func ReplicaSetsGenerate(ctx context.Context, queryContext table.QueryContext) (
 	return results, nil
 }
 
+// ListReplicaSets returns all kubernetes replica sets in all namespaces.
+func ListReplicaSets(ctx context.Context) ([]v1.ReplicaSet, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.ReplicaSet, 0)
+
+	for {
+		rss, err := k8s.GetClient(ctx).AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rss.Items...)
+
+		if rss.Continue == "" {
+			break
+		}
+		options.Continue = rss.Continue
+	}
+
+	return results, nil
+}
+
 type replicaSetContainer struct {
 	k8s.CommonNamespacedFields
 	k8s.CommonContainerFields
@@ -82,7 +104,7 @@ func ReplicaSetContainersGenerate(ctx context.Context, queryContext table.QueryC
 	results := make([]map[string]string, 0)
 
 	for {
-		rss, err := k8s.GetClient().AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
+		rss, err := k8s.GetClient(ctx).AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -146,7 +168,7 @@ func ReplicaSetVolumesGenerate(ctx context.Context, queryContext table.QueryCont
 	results := make([]map[string]string, 0)
 
 	for {
-		rss, err := k8s.GetClient().AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
+		rss, err := k8s.GetClient(ctx).AppsV1().ReplicaSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
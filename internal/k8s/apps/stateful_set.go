@@ -43,7 +43,7 @@ func StatefulSetsGenerate(ctx context.Context, queryContext table.QueryContext)
 	results := make([]map[string]string, 0)
 
 	for {
-		sss, err := k8s.GetClient().AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
+		sss, err := k8s.GetClient(ctx).AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -73,6 +73,28 @@ func StatefulSetsGenerate(ctx context.Context, queryContext table.QueryContext)
 	return results, nil
 }
 
+// ListStatefulSets returns all kubernetes stateful sets in all namespaces.
+func ListStatefulSets(ctx context.Context) ([]v1.StatefulSet, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.StatefulSet, 0)
+
+	for {
+		sss, err := k8s.GetClient(ctx).AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, sss.Items...)
+
+		if sss.Continue == "" {
+			break
+		}
+		options.Continue = sss.Continue
+	}
+
+	return results, nil
+}
+
 type statefulSetContainer struct {
 	k8s.CommonNamespacedFields
 	k8s.CommonContainerFields
@@ -91,7 +113,7 @@ func StatefulSetContainersGenerate(ctx context.Context, queryContext table.Query
 	results := make([]map[string]string, 0)
 
 	for {
-		sss, err := k8s.GetClient().AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
+		sss, err := k8s.GetClient(ctx).AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -155,7 +177,7 @@ func StatefulSetVolumesGenerate(ctx context.Context, queryContext table.QueryCon
 	results := make([]map[string]string, 0)
 
 	for {
-		sss, err := k8s.GetClient().AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
+		sss, err := k8s.GetClient(ctx).AppsV1().StatefulSets(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
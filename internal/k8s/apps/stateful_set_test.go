@@ -77,6 +77,7 @@ func TestStatefulSetContainersGenerate(t *testing.T) {
 			"ports":                      "[{\"name\":\"web\",\"containerPort\":9093,\"protocol\":\"TCP\"},{\"name\":\"mesh-tcp\",\"containerPort\":9094,\"protocol\":\"TCP\"},{\"name\":\"mesh-udp\",\"containerPort\":9094,\"protocol\":\"UDP\"}]",
 			"readiness_probe":            "{\"httpGet\":{\"path\":\"/-/ready\",\"port\":\"web\",\"scheme\":\"HTTP\"},\"initialDelaySeconds\":3,\"timeoutSeconds\":3,\"periodSeconds\":5,\"successThreshold\":1,\"failureThreshold\":10}",
 			"resources":                  "{\"requests\":{\"memory\":\"200Mi\"}}",
+			"memory_request_bytes":       "209715200",
 			"stateful_set_name":          "alertmanager-main",
 			"stdin":                      "0",
 			"stdin_once":                 "0",
@@ -97,6 +98,8 @@ func TestStatefulSetContainersGenerate(t *testing.T) {
 			"name":                       "config-reloader",
 			"namespace":                  "monitoring",
 			"resources":                  "{\"limits\":{\"cpu\":\"100m\",\"memory\":\"25Mi\"}}",
+			"cpu_limit_millicores":       "100",
+			"memory_limit_bytes":         "26214400",
 			"stateful_set_name":          "alertmanager-main",
 			"stdin":                      "0",
 			"stdin_once":                 "0",
@@ -135,7 +138,6 @@ func TestStatefulSetVolumesGenerate(t *testing.T) {
 			"aws_elastic_block_store_partition": "0",
 			"cluster_uid":                       "blah",
 			"creation_timestamp":                "1611191592",
-			"empty_dir_size_limit":              "<nil>",
 			"gce_persistent_disk_partition":     "0",
 			"iscsi_discovery_chap_auth":         "0",
 			"iscsi_lun":                         "0",
@@ -48,7 +48,7 @@ func CronJobsGenerate(ctx context.Context, queryContext table.QueryContext) ([]m
 	results := make([]map[string]string, 0)
 
 	for {
-		cjs, err := k8s.GetClient().BatchV1beta1().CronJobs(metav1.NamespaceAll).List(context.TODO(), options)
+		cjs, err := k8s.GetClient(ctx).BatchV1beta1().CronJobs(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -83,3 +83,25 @@ func CronJobsGenerate(ctx context.Context, queryContext table.QueryContext) ([]m
 
 	return results, nil
 }
+
+// ListCronJobs returns all kubernetes cron jobs in all namespaces.
+func ListCronJobs(ctx context.Context) ([]v1beta1.CronJob, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1beta1.CronJob, 0)
+
+	for {
+		cjs, err := k8s.GetClient(ctx).BatchV1beta1().CronJobs(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, cjs.Items...)
+
+		if cjs.Continue == "" {
+			break
+		}
+		options.Continue = cjs.Continue
+	}
+
+	return results, nil
+}
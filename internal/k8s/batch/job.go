@@ -42,7 +42,7 @@ func JobsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[s
 	results := make([]map[string]string, 0)
 
 	for {
-		jobs, err := k8s.GetClient().BatchV1().Jobs(metav1.NamespaceAll).List(context.TODO(), options)
+		jobs, err := k8s.GetClient(ctx).BatchV1().Jobs(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -71,3 +71,25 @@ func JobsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[s
 
 	return results, nil
 }
+
+// ListJobs returns all kubernetes jobs in all namespaces.
+func ListJobs(ctx context.Context) ([]v1.Job, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Job, 0)
+
+	for {
+		jobs, err := k8s.GetClient(ctx).BatchV1().Jobs(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, jobs.Items...)
+
+		if jobs.Continue == "" {
+			break
+		}
+		options.Continue = jobs.Continue
+	}
+
+	return results, nil
+}
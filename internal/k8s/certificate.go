@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package k8s
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateFields contains X.509 certificate fields as a flat structure.
+type CertificateFields struct {
+	CertificateSubject            string
+	CertificateIssuer             string
+	CertificateSerialNumber       string
+	CertificateSubjectAltNames    []string
+	CertificatePublicKeyAlgorithm string
+	CertificatePublicKeySize      *int
+	CertificateNotBefore          *metav1.Time
+	CertificateNotAfter           *metav1.Time
+}
+
+func publicKeySize(c *x509.Certificate) int {
+	switch k := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return len(k) * 8
+	}
+	return 0
+}
+
+// ParseCertificates returns all X.509 certificates from PEM encoded data. Data without any PEM blocks is
+// parsed as DER encoded certificates. PEM blocks that are not certificates or fail to parse are ignored.
+func ParseCertificates(data []byte) []*x509.Certificate {
+	certs := make([]*x509.Certificate, 0)
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(rest) == len(data) {
+		if der, err := x509.ParseCertificates(data); err == nil {
+			certs = append(certs, der...)
+		}
+	}
+	return certs
+}
+
+// GetCertificateFields converts X.509 certificate to CertificateFields structure.
+// DNS names, IP addresses, email addresses and URIs are all reported as subject alternative names.
+func GetCertificateFields(c *x509.Certificate) CertificateFields {
+	sans := make([]string, 0)
+	sans = append(sans, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, c.EmailAddresses...)
+	for _, uri := range c.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	keySize := publicKeySize(c)
+	notBefore := metav1.NewTime(c.NotBefore)
+	notAfter := metav1.NewTime(c.NotAfter)
+	return CertificateFields{
+		CertificateSubject:            c.Subject.String(),
+		CertificateIssuer:             c.Issuer.String(),
+		CertificateSerialNumber:       fmt.Sprintf("%X", c.SerialNumber),
+		CertificateSubjectAltNames:    sans,
+		CertificatePublicKeyAlgorithm: c.PublicKeyAlgorithm.String(),
+		CertificatePublicKeySize:      &keySize,
+		CertificateNotBefore:          &notBefore,
+		CertificateNotAfter:           &notAfter,
+	}
+}
@@ -0,0 +1,207 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/core"
+	"github.com/Uptycs/kubequery/internal/k8s/networking"
+	"github.com/Uptycs/kubequery/internal/k8s/policy"
+	"github.com/Uptycs/kubequery/internal/k8s/rbac"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CIS Kubernetes Benchmark revisions.
+const (
+	version16  = "cis-1.6"
+	version120 = "cis-1.20"
+
+	defaultVersion = version120
+)
+
+// Control statuses.
+const (
+	statusPass   = "pass"
+	statusFail   = "fail"
+	statusManual = "manual"
+)
+
+// errUnknownVersion is returned when the version constraint is not a supported benchmark revision.
+var errUnknownVersion = errors.New("unknown CIS benchmark version")
+
+var versions = []string{version16, version120}
+
+// objectRef identifies an object that fails a control.
+type objectRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func newObjectRef(kind string, meta metav1.ObjectMeta) objectRef {
+	return objectRef{Kind: kind, Namespace: meta.Namespace, Name: meta.Name}
+}
+
+// inventory holds the objects that controls are evaluated against. Workloads only include top level
+// objects, not the pods and replica sets created by controllers.
+type inventory struct {
+	roles               []rbacv1.Role
+	clusterRoles        []rbacv1.ClusterRole
+	roleBindings        []rbacv1.RoleBinding
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+	serviceAccounts     []corev1.ServiceAccount
+	namespaces          []corev1.Namespace
+	services            []corev1.Service
+	networkPolicies     []networkingv1.NetworkPolicy
+	podSecurityPolicies []v1beta1.PodSecurityPolicy
+	workloads           []workload.Workload
+}
+
+func loadInventory(ctx context.Context) (*inventory, error) {
+	var err error
+	inv := &inventory{}
+
+	if inv.roles, err = rbac.ListRoles(ctx); err != nil {
+		return nil, err
+	}
+	if inv.clusterRoles, err = rbac.ListClusterRoles(ctx); err != nil {
+		return nil, err
+	}
+	if inv.roleBindings, err = rbac.ListRoleBindings(ctx); err != nil {
+		return nil, err
+	}
+	if inv.clusterRoleBindings, err = rbac.ListClusterRoleBindings(ctx); err != nil {
+		return nil, err
+	}
+	if inv.serviceAccounts, err = core.ListServiceAccounts(ctx); err != nil {
+		return nil, err
+	}
+	if inv.namespaces, err = core.ListNamespaces(ctx); err != nil {
+		return nil, err
+	}
+	if inv.services, err = core.ListServices(ctx); err != nil {
+		return nil, err
+	}
+	if inv.networkPolicies, err = networking.ListNetworkPolicies(ctx); err != nil {
+		return nil, err
+	}
+	// Pod security policy API is not served by newer clusters
+	if inv.podSecurityPolicies, err = policy.ListPodSecurityPolicies(ctx); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range ws {
+		if metav1.GetControllerOf(&w.ObjectMeta) == nil {
+			inv.workloads = append(inv.workloads, w)
+		}
+	}
+
+	return inv, nil
+}
+
+// control is a benchmark recommendation. Controls without evaluate function can not be verified using
+// kubernetes API and are reported as manual.
+type control struct {
+	id          string
+	title       string
+	remediation string
+	versions    []string
+	evaluate    func(inv *inventory) (string, []objectRef)
+}
+
+func (c control) in(version string) bool {
+	for _, v := range c.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// failIfAny returns fail status if there are offending objects.
+func failIfAny(objects []objectRef) (string, []objectRef) {
+	if len(objects) > 0 {
+		return statusFail, objects
+	}
+	return statusPass, nil
+}
+
+type cisCheck struct {
+	ClusterUID  types.UID
+	Version     string
+	ID          string
+	Title       string
+	Status      string
+	Objects     []objectRef
+	Remediation string
+}
+
+// CISCheckColumns returns CIS Kubernetes Benchmark check fields as Osquery table columns.
+func CISCheckColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&cisCheck{})
+}
+
+// CISChecksGenerate generates the results of CIS Kubernetes Benchmark controls as Osquery table data.
+// Benchmark revision is selected using version constraint and defaults to the latest supported revision.
+func CISChecksGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	version, ok := k8s.GetConstraint(queryContext, "version")
+	if !ok {
+		version = defaultVersion
+	}
+	supported := false
+	for _, v := range versions {
+		supported = supported || v == version
+	}
+	if !supported {
+		return nil, errUnknownVersion
+	}
+
+	inv, err := loadInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, c := range controls {
+		if !c.in(version) {
+			continue
+		}
+
+		item := &cisCheck{
+			ClusterUID:  k8s.GetClusterUID(),
+			Version:     version,
+			ID:          c.id,
+			Title:       c.title,
+			Status:      statusManual,
+			Remediation: c.remediation,
+		}
+		if c.evaluate != nil {
+			item.Status, item.Objects = c.evaluate(inv)
+		}
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,124 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func getVersionConstraint(version string) table.QueryContext {
+	return table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"version": {
+				Affinity:    table.ColumnTypeText,
+				Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: version}},
+			},
+		},
+	}
+}
+
+func TestCISChecksGenerate(t *testing.T) {
+	checks, err := CISChecksGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+
+	statuses := make(map[string]string)
+	objects := make(map[string]string)
+	for _, c := range checks {
+		assert.Equal(t, "c123", c["cluster_uid"])
+		assert.Equal(t, version120, c["version"])
+		assert.NotEmpty(t, c["title"])
+		assert.NotEmpty(t, c["remediation"])
+		statuses[c["id"]] = c["status"]
+		objects[c["id"]] = c["objects"]
+	}
+
+	assert.Equal(t, map[string]string{
+		"5.1.1": statusFail,
+		"5.1.2": statusFail,
+		"5.1.3": statusPass,
+		"5.1.4": statusFail,
+		"5.1.5": statusFail,
+		"5.1.6": statusFail,
+		"5.1.7": statusManual,
+		"5.1.8": statusFail,
+		"5.2.1": statusPass,
+		"5.2.2": statusPass,
+		"5.2.3": statusPass,
+		"5.2.4": statusPass,
+		"5.2.5": statusPass,
+		"5.2.6": statusPass,
+		"5.2.7": statusPass,
+		"5.2.8": statusPass,
+		"5.2.9": statusManual,
+		"5.3.1": statusManual,
+		"5.3.2": statusFail,
+		"5.4.1": statusFail,
+		"5.4.2": statusManual,
+		"5.5.1": statusManual,
+		"5.7.1": statusManual,
+		"5.7.2": statusFail,
+		"5.7.3": statusManual,
+		"5.7.4": statusFail,
+	}, statuses)
+
+	assert.Equal(t, `[{"kind":"ClusterRoleBinding","name":"ops-admin"}]`, objects["5.1.1"])
+	assert.Equal(t, `[{"kind":"ClusterRole","name":"secret-reader"}]`, objects["5.1.2"])
+	assert.Equal(t, "", objects["5.1.3"])
+	assert.Equal(t, `[{"kind":"Role","namespace":"team","name":"pod-creator"}]`, objects["5.1.4"])
+	assert.Equal(t, `[{"kind":"ServiceAccount","namespace":"default","name":"default"},{"kind":"RoleBinding","namespace":"team","name":"default-secret-reader"}]`, objects["5.1.5"])
+	assert.Equal(t, `[{"kind":"Pod","namespace":"default","name":"debug"}]`, objects["5.1.6"])
+	assert.Equal(t, `[{"kind":"ClusterRole","name":"impersonator"}]`, objects["5.1.8"])
+	assert.Equal(t, `[{"kind":"Deployment","namespace":"team","name":"app"}]`, objects["5.4.1"])
+	assert.Equal(t, `[{"kind":"Pod","namespace":"default","name":"debug"}]`, objects["5.7.2"])
+	assert.Equal(t, `[{"kind":"Pod","namespace":"default","name":"debug"},{"kind":"Service","namespace":"default","name":"web"}]`, objects["5.7.4"])
+}
+
+func TestCISChecksGenerateWithoutPodSecurityPolicies(t *testing.T) {
+	fc := k8s.GetClient(context.TODO()).(*fake.Clientset)
+	chain := fc.ReactionChain
+	defer func() { fc.ReactionChain = chain }()
+	fc.PrependReactor("list", "podsecuritypolicies", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+
+	checks, err := CISChecksGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	for _, c := range checks {
+		if strings.HasPrefix(c["id"], "5.2.") {
+			assert.Equal(t, statusManual, c["status"], c["id"])
+			assert.Equal(t, "", c["objects"], c["id"])
+		}
+	}
+}
+
+func TestCISChecksGenerateVersion(t *testing.T) {
+	checks, err := CISChecksGenerate(context.TODO(), getVersionConstraint(version16))
+	assert.Nil(t, err)
+	assert.Len(t, checks, 24)
+	for _, c := range checks {
+		assert.Equal(t, version16, c["version"])
+		assert.NotEqual(t, "5.1.7", c["id"])
+		assert.NotEqual(t, "5.1.8", c["id"])
+	}
+
+	checks, err = CISChecksGenerate(context.TODO(), getVersionConstraint("cis-1.0"))
+	assert.Equal(t, errUnknownVersion, err)
+	assert.Nil(t, checks)
+}
@@ -0,0 +1,120 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cis
+
+import (
+	"github.com/Uptycs/kubequery/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func init() {
+	yes, no := true, false
+	defaults := map[string]string{bootstrapLabel: "rbac-defaults"}
+	meta := func(namespace, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + "/" + name)}
+	}
+
+	objects := []runtime.Object{
+		&v1.Namespace{ObjectMeta: meta("", "default")},
+		&v1.Namespace{ObjectMeta: meta("", "kube-system")},
+		&v1.Namespace{ObjectMeta: meta("", "team")},
+		&networkingv1.NetworkPolicy{ObjectMeta: meta("team", "deny-all")},
+
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: defaults},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: meta("", "secret-reader"),
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: meta("", "impersonator"),
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"users"}, Verbs: []string{"impersonate"}}},
+		},
+		&rbacv1.Role{
+			ObjectMeta: meta("team", "pod-creator"),
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "delete"}}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: defaults},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:masters"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: meta("", "ops-admin"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "ops"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("team", "default-secret-reader"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "default", Namespace: "team"}},
+		},
+
+		&v1.ServiceAccount{ObjectMeta: meta("default", "default")},
+		&v1.ServiceAccount{ObjectMeta: meta("team", "default"), AutomountServiceAccountToken: &no},
+		&v1.ServiceAccount{ObjectMeta: meta("team", "app"), AutomountServiceAccountToken: &no},
+
+		&v1beta1.PodSecurityPolicy{
+			ObjectMeta: meta("", "privileged"),
+			Spec:       v1beta1.PodSecurityPolicySpec{Privileged: true, HostPID: true, HostIPC: true, HostNetwork: true},
+		},
+		&v1beta1.PodSecurityPolicy{
+			ObjectMeta: meta("", "restricted"),
+			Spec: v1beta1.PodSecurityPolicySpec{
+				AllowPrivilegeEscalation: &no,
+				RunAsUser:                v1beta1.RunAsUserStrategyOptions{Rule: v1beta1.RunAsUserStrategyMustRunAsNonRoot},
+				RequiredDropCapabilities: []v1.Capability{"ALL"},
+			},
+		},
+
+		&v1.Service{ObjectMeta: meta("default", "kubernetes")},
+		&v1.Service{ObjectMeta: meta("default", "web")},
+
+		&appsv1.Deployment{
+			ObjectMeta: meta("team", "app"),
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						ServiceAccountName: "app",
+						SecurityContext:    &v1.PodSecurityContext{SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}},
+						Containers: []v1.Container{{
+							Name:    "app",
+							EnvFrom: []v1.EnvFromSource{{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "creds"}}}},
+						}},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "app-1",
+				Namespace:       "team",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "app-1", Controller: &yes}},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+		},
+		&v1.Pod{
+			ObjectMeta: meta("default", "debug"),
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "debug"}}},
+		},
+	}
+
+	k8s.SetClient(fake.NewSimpleClientset(objects...), types.UID("c123"))
+}
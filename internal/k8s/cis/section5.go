@@ -0,0 +1,495 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cis
+
+import (
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s/rbac"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// bootstrapLabel is set on default roles and bindings created by API server.
+	bootstrapLabel = "kubernetes.io/bootstrapping"
+
+	seccompPodAnnotation = "seccomp.security.alpha.kubernetes.io/pod"
+)
+
+// controls are the API observable recommendations of section 5 (Policies).
+var controls = []control{
+	{
+		id:          "5.1.1",
+		title:       "Ensure that the cluster-admin role is only used where required",
+		remediation: "Identify all clusterrolebindings to the cluster-admin role. Check if they are used and if they need this role or if they could use a role with fewer privileges.",
+		versions:    []string{version16, version120},
+		evaluate:    checkClusterAdminBindings,
+	},
+	{
+		id:          "5.1.2",
+		title:       "Minimize access to secrets",
+		remediation: "Where possible, remove get, list and watch access to secret objects in the cluster.",
+		versions:    []string{version16, version120},
+		evaluate: func(inv *inventory) (string, []objectRef) {
+			return failIfAny(inv.rolesWithRule(func(r rbacv1.PolicyRule) bool {
+				return rbac.RuleAllows(r, "get", "", "secrets") ||
+					rbac.RuleAllows(r, "list", "", "secrets") ||
+					rbac.RuleAllows(r, "watch", "", "secrets")
+			}))
+		},
+	},
+	{
+		id:          "5.1.3",
+		title:       "Minimize wildcard use in Roles and ClusterRoles",
+		remediation: "Where possible replace any use of wildcards in clusterroles and roles with specific objects or actions.",
+		versions:    []string{version16, version120},
+		evaluate: func(inv *inventory) (string, []objectRef) {
+			return failIfAny(inv.rolesWithRule(func(r rbacv1.PolicyRule) bool {
+				return contains(r.Verbs, rbacv1.VerbAll) || contains(r.Resources, rbacv1.ResourceAll) ||
+					contains(r.APIGroups, rbacv1.APIGroupAll)
+			}))
+		},
+	},
+	{
+		id:          "5.1.4",
+		title:       "Minimize access to create pods",
+		remediation: "Where possible, remove create access to pod objects in the cluster.",
+		versions:    []string{version16, version120},
+		evaluate: func(inv *inventory) (string, []objectRef) {
+			return failIfAny(inv.rolesWithRule(func(r rbacv1.PolicyRule) bool {
+				return rbac.RuleAllows(r, "create", "", "pods")
+			}))
+		},
+	},
+	{
+		id:          "5.1.5",
+		title:       "Ensure that default service accounts are not actively used",
+		remediation: "Create explicit service accounts wherever a Kubernetes workload requires specific access to the Kubernetes API server. Modify the configuration of each default service account to include automountServiceAccountToken: false.",
+		versions:    []string{version16, version120},
+		evaluate:    checkDefaultServiceAccounts,
+	},
+	{
+		id:          "5.1.6",
+		title:       "Ensure that Service Account Tokens are only mounted where necessary",
+		remediation: "Modify the definition of pods and service accounts which do not need to mount service account tokens to disable it.",
+		versions:    []string{version16, version120},
+		evaluate:    checkServiceAccountTokens,
+	},
+	{
+		id:          "5.1.7",
+		title:       "Avoid use of system:masters group",
+		remediation: "Remove the system:masters group from all users in the cluster.",
+		versions:    []string{version120},
+	},
+	{
+		id:          "5.1.8",
+		title:       "Limit use of the Bind, Impersonate and Escalate permissions in the Kubernetes cluster",
+		remediation: "Where possible, remove the impersonate, bind and escalate rights from subjects.",
+		versions:    []string{version120},
+		evaluate: func(inv *inventory) (string, []objectRef) {
+			return failIfAny(inv.rolesWithRule(func(r rbacv1.PolicyRule) bool {
+				for _, resource := range []string{"roles", "clusterroles"} {
+					if rbac.RuleAllows(r, "bind", rbacv1.GroupName, resource) || rbac.RuleAllows(r, "escalate", rbacv1.GroupName, resource) {
+						return true
+					}
+				}
+				for _, resource := range []string{"users", "groups", "serviceaccounts"} {
+					if rbac.RuleAllows(r, "impersonate", "", resource) {
+						return true
+					}
+				}
+				return false
+			}))
+		},
+	},
+	{
+		id:          "5.2.1",
+		title:       "Minimize the admission of privileged containers",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.privileged field is omitted or set to false.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return !spec.Privileged
+		}),
+	},
+	{
+		id:          "5.2.2",
+		title:       "Minimize the admission of containers wishing to share the host process ID namespace",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.hostPID field is omitted or set to false.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return !spec.HostPID
+		}),
+	},
+	{
+		id:          "5.2.3",
+		title:       "Minimize the admission of containers wishing to share the host IPC namespace",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.hostIPC field is omitted or set to false.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return !spec.HostIPC
+		}),
+	},
+	{
+		id:          "5.2.4",
+		title:       "Minimize the admission of containers wishing to share the host network namespace",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.hostNetwork field is omitted or set to false.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return !spec.HostNetwork
+		}),
+	},
+	{
+		id:          "5.2.5",
+		title:       "Minimize the admission of containers with allowPrivilegeEscalation",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.allowPrivilegeEscalation field is set to false.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return spec.AllowPrivilegeEscalation != nil && !*spec.AllowPrivilegeEscalation
+		}),
+	},
+	{
+		id:          "5.2.6",
+		title:       "Minimize the admission of root containers",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.runAsUser.rule is set to either MustRunAsNonRoot or MustRunAs with the range of UIDs not including 0.",
+		versions:    []string{version16, version120},
+		evaluate:    checkPodSecurityPolicies(forbidsRoot),
+	},
+	{
+		id:          "5.2.7",
+		title:       "Minimize the admission of containers with the NET_RAW capability",
+		remediation: "Create a PSP as described in the Kubernetes documentation, ensuring that the .spec.requiredDropCapabilities is set to include either NET_RAW or ALL.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return containsCapability(spec.RequiredDropCapabilities, "NET_RAW") || containsCapability(spec.RequiredDropCapabilities, "ALL")
+		}),
+	},
+	{
+		id:          "5.2.8",
+		title:       "Minimize the admission of containers with added capabilities",
+		remediation: "Ensure that allowedCapabilities is not present in PSPs for the cluster unless it is set to an empty array.",
+		versions:    []string{version16, version120},
+		evaluate: checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+			return len(spec.AllowedCapabilities) == 0
+		}),
+	},
+	{
+		id:          "5.2.9",
+		title:       "Minimize the admission of containers with capabilities assigned",
+		remediation: "Review the use of capabilities in applications running on your cluster. Where a namespace contains applications which do not require any Linux capabilities to operate consider adding a PSP which forbids the admission of containers which do not drop all capabilities.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.3.1",
+		title:       "Ensure that the CNI in use supports Network Policies",
+		remediation: "If the CNI plugin in use does not support network policies, consideration should be given to making use of a different plugin, or finding an alternate mechanism for restricting traffic in the Kubernetes cluster.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.3.2",
+		title:       "Ensure that all Namespaces have Network Policies defined",
+		remediation: "Follow the documentation and create NetworkPolicy objects as you need them.",
+		versions:    []string{version16, version120},
+		evaluate:    checkNamespaceNetworkPolicies,
+	},
+	{
+		id:          "5.4.1",
+		title:       "Prefer using secrets as files over secrets as environment variables",
+		remediation: "If possible, rewrite application code to read secrets from mounted secret files, rather than from environment variables.",
+		versions:    []string{version16, version120},
+		evaluate:    checkSecretEnvironmentVariables,
+	},
+	{
+		id:          "5.4.2",
+		title:       "Consider external secret storage",
+		remediation: "Refer to the secrets management options offered by your cloud provider or a third-party secrets management solution.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.5.1",
+		title:       "Configure Image Provenance using ImagePolicyWebhook admission controller",
+		remediation: "Follow the Kubernetes documentation and setup image provenance.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.7.1",
+		title:       "Create administrative boundaries between resources using namespaces",
+		remediation: "Follow the documentation and create namespaces for objects in your deployment as you need them.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.7.2",
+		title:       "Ensure that the seccomp profile is set to docker/default in your pod definitions",
+		remediation: "Use security context to enable the docker/default seccomp profile in your pod definitions.",
+		versions:    []string{version16, version120},
+		evaluate:    checkSeccompProfiles,
+	},
+	{
+		id:          "5.7.3",
+		title:       "Apply Security Context to Your Pods and Containers",
+		remediation: "Follow the Kubernetes documentation and apply security contexts to your pods.",
+		versions:    []string{version16, version120},
+	},
+	{
+		id:          "5.7.4",
+		title:       "The default namespace should not be used",
+		remediation: "Ensure that namespaces are created to allow for appropriate segregation of Kubernetes resources and that all new resources are created in a specific namespace.",
+		versions:    []string{version16, version120},
+		evaluate:    checkDefaultNamespace,
+	},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCapability(values []corev1.Capability, value corev1.Capability) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefault returns true for roles and bindings that are created by API server.
+func isDefault(meta metav1.ObjectMeta) bool {
+	return meta.Labels[bootstrapLabel] == "rbac-defaults" || strings.HasPrefix(meta.Name, "system:")
+}
+
+// rolesWithRule returns the roles and cluster roles that have any rule matching the predicate. Default roles are ignored.
+func (inv *inventory) rolesWithRule(matches func(r rbacv1.PolicyRule) bool) []objectRef {
+	results := make([]objectRef, 0)
+	for _, cr := range inv.clusterRoles {
+		if isDefault(cr.ObjectMeta) {
+			continue
+		}
+		for _, r := range cr.Rules {
+			if matches(r) {
+				results = append(results, newObjectRef("ClusterRole", cr.ObjectMeta))
+				break
+			}
+		}
+	}
+	for _, role := range inv.roles {
+		if isDefault(role.ObjectMeta) {
+			continue
+		}
+		for _, r := range role.Rules {
+			if matches(r) {
+				results = append(results, newObjectRef("Role", role.ObjectMeta))
+				break
+			}
+		}
+	}
+	return results
+}
+
+func checkClusterAdminBindings(inv *inventory) (string, []objectRef) {
+	isClusterAdmin := func(ref rbacv1.RoleRef) bool {
+		return ref.Kind == "ClusterRole" && ref.Name == "cluster-admin"
+	}
+
+	results := make([]objectRef, 0)
+	for _, crb := range inv.clusterRoleBindings {
+		if isClusterAdmin(crb.RoleRef) && !isDefault(crb.ObjectMeta) {
+			results = append(results, newObjectRef("ClusterRoleBinding", crb.ObjectMeta))
+		}
+	}
+	for _, rb := range inv.roleBindings {
+		if isClusterAdmin(rb.RoleRef) && !isDefault(rb.ObjectMeta) {
+			results = append(results, newObjectRef("RoleBinding", rb.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+func checkDefaultServiceAccounts(inv *inventory) (string, []objectRef) {
+	results := make([]objectRef, 0)
+	for _, sa := range inv.serviceAccounts {
+		if sa.Name == "default" && (sa.AutomountServiceAccountToken == nil || *sa.AutomountServiceAccountToken) {
+			results = append(results, newObjectRef("ServiceAccount", sa.ObjectMeta))
+		}
+	}
+
+	bindsDefault := func(subjects []rbacv1.Subject) bool {
+		for _, s := range subjects {
+			if s.Kind == rbacv1.ServiceAccountKind && s.Name == "default" {
+				return true
+			}
+		}
+		return false
+	}
+	for _, crb := range inv.clusterRoleBindings {
+		if bindsDefault(crb.Subjects) {
+			results = append(results, newObjectRef("ClusterRoleBinding", crb.ObjectMeta))
+		}
+	}
+	for _, rb := range inv.roleBindings {
+		if bindsDefault(rb.Subjects) {
+			results = append(results, newObjectRef("RoleBinding", rb.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+func checkServiceAccountTokens(inv *inventory) (string, []objectRef) {
+	automount := make(map[string]*bool, len(inv.serviceAccounts))
+	for _, sa := range inv.serviceAccounts {
+		automount[sa.Namespace+"/"+sa.Name] = sa.AutomountServiceAccountToken
+	}
+
+	results := make([]objectRef, 0)
+	for _, w := range inv.workloads {
+		enabled := w.Spec.AutomountServiceAccountToken
+		if enabled == nil {
+			name := w.Spec.ServiceAccountName
+			if name == "" {
+				name = "default"
+			}
+			enabled = automount[w.ObjectMeta.Namespace+"/"+name]
+		}
+		if enabled == nil || *enabled {
+			results = append(results, newObjectRef(w.Kind, w.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+// checkPodSecurityPolicies returns a function that passes if there is at least one pod security policy
+// that restricts the setting. Policies that do not restrict it are reported otherwise. Without pod security
+// policies the setting may be enforced by other admission controllers, which requires a manual review.
+func checkPodSecurityPolicies(restricts func(spec v1beta1.PodSecurityPolicySpec) bool) func(inv *inventory) (string, []objectRef) {
+	return func(inv *inventory) (string, []objectRef) {
+		if len(inv.podSecurityPolicies) == 0 {
+			return statusManual, nil
+		}
+
+		results := make([]objectRef, 0)
+		for _, psp := range inv.podSecurityPolicies {
+			if restricts(psp.Spec) {
+				return statusPass, nil
+			}
+			results = append(results, newObjectRef("PodSecurityPolicy", psp.ObjectMeta))
+		}
+		return statusFail, results
+	}
+}
+
+func forbidsRoot(spec v1beta1.PodSecurityPolicySpec) bool {
+	switch spec.RunAsUser.Rule {
+	case v1beta1.RunAsUserStrategyMustRunAsNonRoot:
+		return true
+	case v1beta1.RunAsUserStrategyMustRunAs:
+		if len(spec.RunAsUser.Ranges) == 0 {
+			return false
+		}
+		for _, r := range spec.RunAsUser.Ranges {
+			if r.Min == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func checkNamespaceNetworkPolicies(inv *inventory) (string, []objectRef) {
+	hasPolicy := make(map[string]bool)
+	for _, np := range inv.networkPolicies {
+		hasPolicy[np.Namespace] = true
+	}
+
+	results := make([]objectRef, 0)
+	for _, ns := range inv.namespaces {
+		if !hasPolicy[ns.Name] {
+			results = append(results, newObjectRef("Namespace", ns.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+func usesSecretEnvironmentVariables(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) bool {
+	for _, e := range env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			return true
+		}
+	}
+	for _, e := range envFrom {
+		if e.SecretRef != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSecretEnvironmentVariables(inv *inventory) (string, []objectRef) {
+	results := make([]objectRef, 0)
+	for _, w := range inv.workloads {
+		found := false
+		for _, c := range w.Spec.InitContainers {
+			found = found || usesSecretEnvironmentVariables(c.Env, c.EnvFrom)
+		}
+		for _, c := range w.Spec.Containers {
+			found = found || usesSecretEnvironmentVariables(c.Env, c.EnvFrom)
+		}
+		for _, c := range w.Spec.EphemeralContainers {
+			found = found || usesSecretEnvironmentVariables(c.Env, c.EnvFrom)
+		}
+		if found {
+			results = append(results, newObjectRef(w.Kind, w.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+// hasSeccompProfile returns true if the pod level seccomp profile is set to runtime default or a local profile
+// using security context or deprecated annotation.
+func hasSeccompProfile(annotations map[string]string, spec corev1.PodSpec) bool {
+	if spec.SecurityContext != nil && spec.SecurityContext.SeccompProfile != nil {
+		t := spec.SecurityContext.SeccompProfile.Type
+		return t == corev1.SeccompProfileTypeRuntimeDefault || t == corev1.SeccompProfileTypeLocalhost
+	}
+
+	profile := annotations[seccompPodAnnotation]
+	return profile == "runtime/default" || profile == "docker/default" || strings.HasPrefix(profile, "localhost/")
+}
+
+func checkSeccompProfiles(inv *inventory) (string, []objectRef) {
+	results := make([]objectRef, 0)
+	for _, w := range inv.workloads {
+		if !hasSeccompProfile(w.Template.Annotations, w.Spec) {
+			results = append(results, newObjectRef(w.Kind, w.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
+
+func checkDefaultNamespace(inv *inventory) (string, []objectRef) {
+	results := make([]objectRef, 0)
+	for _, w := range inv.workloads {
+		if w.ObjectMeta.Namespace == metav1.NamespaceDefault {
+			results = append(results, newObjectRef(w.Kind, w.ObjectMeta))
+		}
+	}
+	for _, s := range inv.services {
+		if s.Namespace == metav1.NamespaceDefault && s.Name != "kubernetes" {
+			results = append(results, newObjectRef("Service", s.ObjectMeta))
+		}
+	}
+	return failIfAny(results)
+}
@@ -0,0 +1,65 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckPodSecurityPolicies(t *testing.T) {
+	check := checkPodSecurityPolicies(func(spec v1beta1.PodSecurityPolicySpec) bool {
+		return !spec.Privileged
+	})
+
+	status, objects := check(&inventory{})
+	assert.Equal(t, statusManual, status)
+	assert.Nil(t, objects)
+
+	privileged := v1beta1.PodSecurityPolicy{ObjectMeta: metav1.ObjectMeta{Name: "privileged"}, Spec: v1beta1.PodSecurityPolicySpec{Privileged: true}}
+	status, objects = check(&inventory{podSecurityPolicies: []v1beta1.PodSecurityPolicy{privileged}})
+	assert.Equal(t, statusFail, status)
+	assert.Equal(t, []objectRef{newObjectRef("PodSecurityPolicy", privileged.ObjectMeta)}, objects)
+
+	restricted := v1beta1.PodSecurityPolicy{ObjectMeta: metav1.ObjectMeta{Name: "restricted"}}
+	status, objects = check(&inventory{podSecurityPolicies: []v1beta1.PodSecurityPolicy{privileged, restricted}})
+	assert.Equal(t, statusPass, status)
+	assert.Nil(t, objects)
+}
+
+func TestForbidsRoot(t *testing.T) {
+	spec := func(rule v1beta1.RunAsUserStrategy, ranges ...v1beta1.IDRange) v1beta1.PodSecurityPolicySpec {
+		return v1beta1.PodSecurityPolicySpec{RunAsUser: v1beta1.RunAsUserStrategyOptions{Rule: rule, Ranges: ranges}}
+	}
+
+	assert.True(t, forbidsRoot(spec(v1beta1.RunAsUserStrategyMustRunAsNonRoot)))
+	assert.True(t, forbidsRoot(spec(v1beta1.RunAsUserStrategyMustRunAs, v1beta1.IDRange{Min: 1000, Max: 2000})))
+	assert.False(t, forbidsRoot(spec(v1beta1.RunAsUserStrategyMustRunAs, v1beta1.IDRange{Min: 0, Max: 2000})))
+	assert.False(t, forbidsRoot(spec(v1beta1.RunAsUserStrategyMustRunAs)))
+	assert.False(t, forbidsRoot(spec(v1beta1.RunAsUserStrategyRunAsAny)))
+}
+
+func TestHasSeccompProfile(t *testing.T) {
+	profile := func(t v1.SeccompProfileType) v1.PodSpec {
+		return v1.PodSpec{SecurityContext: &v1.PodSecurityContext{SeccompProfile: &v1.SeccompProfile{Type: t}}}
+	}
+
+	assert.True(t, hasSeccompProfile(nil, profile(v1.SeccompProfileTypeRuntimeDefault)))
+	assert.True(t, hasSeccompProfile(nil, profile(v1.SeccompProfileTypeLocalhost)))
+	assert.False(t, hasSeccompProfile(nil, profile(v1.SeccompProfileTypeUnconfined)))
+	assert.False(t, hasSeccompProfile(nil, v1.PodSpec{}))
+	assert.True(t, hasSeccompProfile(map[string]string{seccompPodAnnotation: "docker/default"}, v1.PodSpec{}))
+	assert.True(t, hasSeccompProfile(map[string]string{seccompPodAnnotation: "localhost/profile.json"}, v1.PodSpec{}))
+	assert.False(t, hasSeccompProfile(map[string]string{seccompPodAnnotation: "unconfined"}, v1.PodSpec{}))
+}
@@ -11,20 +11,109 @@ package k8s
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
 	"sync"
+	"time"
 
+	"github.com/kolide/osquery-go/plugin/table"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	aggregator "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
+// ErrNotReady is returned by tables that are queried before the kubernetes client is initialized.
+var ErrNotReady = errors.New("kubequery is not ready: kubernetes cluster identity is not initialized yet")
+
+// Cluster UID sources. ClusterUIDSourceAuto uses kube-system namespace UID and falls back to API server
+// derived UID if reading kube-system namespace is forbidden.
+const (
+	ClusterUIDSourceAuto       = "auto"
+	ClusterUIDSourceFlag       = "flag"
+	ClusterUIDSourceKubeSystem = "kube-system"
+	ClusterUIDSourceAPIServer  = "api-server"
+)
+
+// Options contains kubernetes client and cluster identity configuration.
+type Options struct {
+	// ClusterUID is used as cluster identity as is if it is not empty.
+	ClusterUID string
+	// ClusterName is reported as cluster_name column in all tables.
+	ClusterName string
+	// ClusterUIDSource is one of auto, kube-system or api-server. Defaults to auto.
+	ClusterUIDSource string
+	// ImpersonateUser and ImpersonateGroups are used to impersonate all API server requests.
+	ImpersonateUser   string
+	ImpersonateGroups []string
+	// ImpersonateAllowedUsers are the users that can be impersonated with impersonate_user column.
+	// A trailing * matches any suffix. impersonate_user column is disabled if this is empty.
+	ImpersonateAllowedUsers []string
+}
+
 var (
-	lock       sync.Mutex
-	clientset  kubernetes.Interface
-	clusterUID types.UID
+	lock             sync.RWMutex
+	options          Options
+	restConfig       *rest.Config
+	clients          = &clientSets{}
+	clusterUID       types.UID
+	clusterUIDSource string
+	ready            bool
+	credentialsHash  string
+
+	// Files mounted by kubernetes in every pod with service account token automount enabled.
+	credentialFiles = []string{
+		"/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+	}
+
+	initBackoff = wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    10,
+		Cap:      5 * time.Minute,
+	}
+	revalidateInterval = 5 * time.Minute
 )
 
+// clientSets holds all client sets created from the same configuration.
+type clientSets struct {
+	kubernetes kubernetes.Interface
+	aggregator aggregator.Interface
+	metrics    metrics.Interface
+	metadata   metadata.Interface
+}
+
+func newClientSets(config *rest.Config) (*clientSets, error) {
+	kcs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	acs, err := aggregator.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mcs, err := metrics.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mdcs, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &clientSets{kubernetes: kcs, aggregator: acs, metrics: mcs, metadata: mdcs}, nil
+}
+
 func initClientset(config *rest.Config) error {
 	if config == nil {
 		// Get in-cluster configuration if one is not provided
@@ -35,57 +124,294 @@ func initClientset(config *rest.Config) error {
 		config = conf
 	}
 
-	var err error
-	clientset, err = kubernetes.NewForConfig(config)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if options.ImpersonateUser != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: options.ImpersonateUser,
+			Groups:   options.ImpersonateGroups,
+		}
+	}
+
+	cs, err := newClientSets(config)
 	if err != nil {
 		return err
 	}
+
+	restConfig = config
+	clients = cs
+	impersonatedClients = make(map[string]*impersonatedClientSets)
+	credentialsHash = hashCredentials()
 	return nil
 }
 
+// hashCredentials returns a digest of the service account credential files. Missing files are ignored.
+func hashCredentials() string {
+	h := sha256.New()
+	for _, f := range credentialFiles {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// apiServerUID derives a stable UID from the API server URL and the CA certificate used to verify it.
+func apiServerUID() (types.UID, error) {
+	lock.RLock()
+	config := restConfig
+	lock.RUnlock()
+
+	if config == nil {
+		return "", errors.New("API server configuration is not available")
+	}
+
+	ca := config.TLSClientConfig.CAData
+	if len(ca) == 0 && config.TLSClientConfig.CAFile != "" {
+		data, err := ioutil.ReadFile(config.TLSClientConfig.CAFile)
+		if err != nil {
+			return "", err
+		}
+		ca = data
+	}
+
+	h := sha256.New()
+	h.Write(ca)
+	h.Write([]byte(config.Host))
+	sum := h.Sum(nil)
+	// Format the digest as name based (version 5) UUID
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return types.UID(fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])), nil
+}
+
+func resolveUID() (types.UID, string, error) {
+	lock.RLock()
+	opts := options
+	lock.RUnlock()
+
+	if opts.ClusterUID != "" {
+		return types.UID(opts.ClusterUID), ClusterUIDSourceFlag, nil
+	}
+
+	if opts.ClusterUIDSource == ClusterUIDSourceAPIServer {
+		uid, err := apiServerUID()
+		return uid, ClusterUIDSourceAPIServer, err
+	}
+
+	ks, err := GetClient(context.TODO()).CoreV1().Namespaces().Get(context.TODO(), "kube-system", v1.GetOptions{})
+	if err == nil {
+		return ks.UID, ClusterUIDSourceKubeSystem, nil
+	}
+	if opts.ClusterUIDSource != ClusterUIDSourceKubeSystem && apierrors.IsForbidden(err) {
+		log.Printf("Reading kube-system namespace is forbidden, deriving cluster UID from API server: %s", err)
+		uid, err := apiServerUID()
+		return uid, ClusterUIDSourceAPIServer, err
+	}
+	return "", "", err
+}
+
 func initUID() error {
-	ks, err := GetClient().CoreV1().Namespaces().Get(context.TODO(), "kube-system", v1.GetOptions{})
+	uid, source, err := resolveUID()
 	if err != nil {
 		return err
 	}
-	clusterUID = ks.UID
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if clusterUID != "" && clusterUID != uid {
+		log.Printf("Cluster UID changed from %s to %s", clusterUID, uid)
+	}
+	clusterUID = uid
+	clusterUIDSource = source
+	ready = true
 	return nil
 }
 
+// waitForUID keeps retrying cluster UID initialization with exponential backoff until it succeeds or stop is closed.
+func waitForUID(stop <-chan struct{}) bool {
+	backoff := initBackoff
+	for {
+		err := initUID()
+		if err == nil {
+			return true
+		}
+
+		delay := backoff.Step()
+		log.Printf("Failed to initialize cluster UID, retrying in %s: %s", delay, err)
+		select {
+		case <-stop:
+			return false
+		case <-time.After(delay):
+		}
+	}
+}
+
+// revalidate reloads the client set if the credentials on disk changed and refreshes the cluster UID.
+func revalidate() {
+	lock.RLock()
+	changed := credentialsHash != hashCredentials()
+	lock.RUnlock()
+
+	if changed {
+		log.Printf("Service account credentials changed, reloading kubernetes client")
+		if err := initClientset(nil); err != nil {
+			log.Printf("Failed to reload kubernetes client: %s", err)
+		}
+	}
+
+	if err := initUID(); err != nil {
+		log.Printf("Failed to validate cluster UID: %s", err)
+	}
+}
+
+func run(stop <-chan struct{}) {
+	if waitForUID(stop) {
+		wait.Until(revalidate, revalidateInterval, stop)
+	}
+}
+
 // Init creates in-cluster kubernetes configuration and a client set using the configuration.
 // This returns error if KUBERNETES_SERVICE_HOST or KUBERNETES_SERVICE_PORT environment variables are not set.
-func Init() error {
+// Cluster UID is initialized in the background and retried with exponential backoff until it succeeds.
+// After that the cluster UID and the service account credentials are periodically re-validated.
+func Init(opts Options) error {
+	switch opts.ClusterUIDSource {
+	case "":
+		opts.ClusterUIDSource = ClusterUIDSourceAuto
+	case ClusterUIDSourceAuto, ClusterUIDSourceKubeSystem, ClusterUIDSourceAPIServer:
+	default:
+		return fmt.Errorf("invalid cluster UID source: %s", opts.ClusterUIDSource)
+	}
+	if opts.ImpersonateUser == "" && len(opts.ImpersonateGroups) > 0 {
+		return errors.New("impersonating groups requires a user to impersonate")
+	}
+
 	lock.Lock()
-	defer lock.Unlock()
+	options = opts
+	lock.Unlock()
 
 	err := initClientset(nil)
 	if err != nil {
 		return err
 	}
-	err = initUID()
-	if err != nil {
-		return err
-	}
 
+	go run(wait.NeverStop)
 	return nil
 }
 
+func getClientSets(ctx context.Context) *clientSets {
+	if cs, ok := ctx.Value(clientKey{}).(*clientSets); ok {
+		return cs
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return clients
+}
+
 // GetClient returns kubernetes interface that can be used to communicate with API server.
-func GetClient() kubernetes.Interface {
-	return clientset
+// If the context carries impersonating client sets for the current query, that is returned instead.
+func GetClient(ctx context.Context) kubernetes.Interface {
+	return getClientSets(ctx).kubernetes
+}
+
+// GetAggregatorClient returns kube-aggregator interface that can be used to list API services.
+func GetAggregatorClient(ctx context.Context) aggregator.Interface {
+	return getClientSets(ctx).aggregator
+}
+
+// GetMetricsClient returns metrics interface that can be used to read resource usage from metrics.k8s.io API.
+func GetMetricsClient(ctx context.Context) metrics.Interface {
+	return getClientSets(ctx).metrics
+}
+
+// GetMetadataClient returns metadata interface that can be used to list only the object metadata of any resource.
+func GetMetadataClient(ctx context.Context) metadata.Interface {
+	return getClientSets(ctx).metadata
 }
 
 // GetClusterUID returns unique identifier for the current kubernetes cluster.
-// This is same as the kube-system namespace UID.
+// This is same as the kube-system namespace UID unless configured otherwise.
 func GetClusterUID() types.UID {
+	lock.RLock()
+	defer lock.RUnlock()
+
 	return clusterUID
 }
 
+// GetClusterUIDSource returns how the cluster UID was identified: flag, kube-system or api-server.
+func GetClusterUIDSource() string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return clusterUIDSource
+}
+
+// GetClusterName returns the configured name of the current kubernetes cluster.
+func GetClusterName() string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return options.ClusterName
+}
+
+// IsReady returns true once the kubernetes client and the cluster UID are initialized.
+func IsReady() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return ready
+}
+
+// ReadyGenerate wraps a table generate function so that queries fail with ErrNotReady
+// until the kubernetes client and the cluster UID are initialized.
+func ReadyGenerate(gen table.GenerateFunc) table.GenerateFunc {
+	return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		if !IsReady() {
+			return nil, ErrNotReady
+		}
+		return gen(ctx, queryContext)
+	}
+}
+
 // SetClient is helper function to override the kubernetes interface with fake one for testing.
 func SetClient(c kubernetes.Interface, u types.UID) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	clientset = c
+	clients = &clientSets{kubernetes: c, aggregator: clients.aggregator, metrics: clients.metrics, metadata: clients.metadata}
 	clusterUID = u
+	ready = true
+	impersonatedClients = make(map[string]*impersonatedClientSets)
+	credentialsHash = hashCredentials()
+}
+
+// SetAggregatorClient is helper function to override the kube-aggregator interface with fake one for testing.
+func SetAggregatorClient(c aggregator.Interface) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	clients = &clientSets{kubernetes: clients.kubernetes, aggregator: c, metrics: clients.metrics, metadata: clients.metadata}
+}
+
+// SetMetricsClient is helper function to override the metrics interface with fake one for testing.
+func SetMetricsClient(c metrics.Interface) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	clients = &clientSets{kubernetes: clients.kubernetes, aggregator: clients.aggregator, metrics: c, metadata: clients.metadata}
+}
+
+// SetMetadataClient is helper function to override the metadata interface with fake one for testing.
+func SetMetadataClient(c metadata.Interface) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	clients = &clientSets{kubernetes: clients.kubernetes, aggregator: clients.aggregator, metrics: clients.metrics, metadata: c}
 }
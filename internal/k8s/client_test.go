@@ -10,11 +10,22 @@
 package k8s
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/kolide/osquery-go/plugin/table"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestInitClientset(t *testing.T) {
@@ -24,7 +35,7 @@ func TestInitClientset(t *testing.T) {
 
 func TestGetClient(t *testing.T) {
 	SetClient(fake.NewSimpleClientset(), types.UID(""))
-	clientset := GetClient()
+	clientset := GetClient(context.TODO())
 	assert.NotNil(t, clientset, "Clientset should be valid")
 }
 
@@ -33,3 +44,92 @@ func TestGetClusterUID(t *testing.T) {
 	SetClient(fake.NewSimpleClientset(), uid)
 	assert.Equal(t, uid, GetClusterUID())
 }
+
+func TestReadyGenerate(t *testing.T) {
+	defer SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	gen := ReadyGenerate(func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return []map[string]string{{"a": "b"}}, nil
+	})
+
+	lock.Lock()
+	ready = false
+	lock.Unlock()
+	rows, err := gen(context.TODO(), table.QueryContext{})
+	assert.Equal(t, ErrNotReady, err)
+	assert.Nil(t, rows)
+
+	SetClient(fake.NewSimpleClientset(), types.UID("1234"))
+	rows, err = gen(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"a": "b"}}, rows)
+}
+
+func TestWaitForUID(t *testing.T) {
+	defer SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	SetClient(fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: types.UID("abcd")},
+	}), types.UID(""))
+
+	assert.True(t, waitForUID(wait.NeverStop))
+	assert.Equal(t, types.UID("abcd"), GetClusterUID())
+	assert.True(t, IsReady())
+}
+
+func TestWaitForUIDStop(t *testing.T) {
+	SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, waitForUID(stop))
+}
+
+func TestRevalidate(t *testing.T) {
+	defer SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	client := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: types.UID("abcd")},
+	})
+	SetClient(client, types.UID("1234"))
+
+	revalidate()
+	assert.Equal(t, types.UID("abcd"), GetClusterUID())
+	assert.Equal(t, client, GetClient(context.TODO()))
+}
+
+func TestResolveUID(t *testing.T) {
+	defer func() {
+		options = Options{}
+		restConfig = nil
+	}()
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "kube-system", errors.New("forbidden"))
+	})
+	SetClient(client, types.UID(""))
+	restConfig = &rest.Config{Host: "https://10.0.0.1:443", TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca")}}
+	defer SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	options = Options{ClusterUID: "1234", ClusterUIDSource: ClusterUIDSourceAuto}
+	uid, source, err := resolveUID()
+	assert.Nil(t, err)
+	assert.Equal(t, types.UID("1234"), uid)
+	assert.Equal(t, ClusterUIDSourceFlag, source)
+
+	options = Options{ClusterUIDSource: ClusterUIDSourceAuto}
+	uid, source, err = resolveUID()
+	assert.Nil(t, err)
+	assert.Equal(t, types.UID("d8905b32-f6f4-58f4-9af2-2788c2435172"), uid)
+	assert.Equal(t, ClusterUIDSourceAPIServer, source)
+
+	options = Options{ClusterUIDSource: ClusterUIDSourceKubeSystem}
+	_, _, err = resolveUID()
+	assert.True(t, apierrors.IsForbidden(err))
+}
+
+func TestInitInvalidSource(t *testing.T) {
+	err := Init(Options{ClusterUIDSource: "invalid"})
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ package k8s
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -31,7 +32,7 @@ type CommonFields struct {
 func GetCommonFields(obj metav1.ObjectMeta) CommonFields {
 	return CommonFields{
 		UID:               obj.UID,
-		ClusterName:       obj.ClusterName,
+		ClusterName:       GetClusterName(),
 		ClusterUID:        GetClusterUID(),
 		Name:              obj.Name,
 		CreationTimestamp: obj.CreationTimestamp,
@@ -57,7 +58,7 @@ type CommonNamespacedFields struct {
 func GetCommonNamespacedFields(obj metav1.ObjectMeta) CommonNamespacedFields {
 	return CommonNamespacedFields{
 		UID:               obj.UID,
-		ClusterName:       obj.ClusterName,
+		ClusterName:       GetClusterName(),
 		ClusterUID:        GetClusterUID(),
 		Name:              obj.Name,
 		Namespace:         obj.Namespace,
@@ -365,7 +366,7 @@ type CommonVolumeFields struct {
 	HostPathPath                   string
 	HostPathType                   *v1.HostPathType
 	EmptyDirMedium                 v1.StorageMedium
-	EmptyDirSizeLimit              string
+	EmptyDirSizeLimit              *resource.Quantity
 	GCEPersistentDiskPDName        string
 	GCEPersistentDiskPartition     int32
 	AWSElasticBlockStoreVolumeID   string
@@ -514,12 +515,15 @@ func GetCommonVolumeFields(from v1.Volume) CommonVolumeFields {
 	if from.EmptyDir != nil {
 		to.VolumeType = "empty_dir"
 		to.EmptyDirMedium = from.EmptyDir.Medium
-		to.EmptyDirSizeLimit = from.EmptyDir.SizeLimit.String()
+		to.EmptyDirSizeLimit = from.EmptyDir.SizeLimit
 	}
 	if from.Ephemeral != nil {
 		to.VolumeType = "ephemeral"
 		to.EphemeralVolumeClaimTemplate = from.Ephemeral.VolumeClaimTemplate
-		to.ReadOnly = &from.Ephemeral.ReadOnly
+		// ReadOnly was removed from ephemeral volume source in kubernetes 1.21 because it was never honored.
+		// Ephemeral volumes are always mounted read-write.
+		readOnly := false
+		to.ReadOnly = &readOnly
 	}
 	if from.FC != nil {
 		to.VolumeType = "fc"
@@ -35,7 +35,7 @@ func TestGetCommonFields(t *testing.T) {
 	assert.Equal(t, GetCommonFields(meta), CommonFields{
 		UID:               meta.UID,
 		Name:              meta.Name,
-		ClusterName:       meta.ClusterName,
+		ClusterName:       "",
 		ClusterUID:        "",
 		CreationTimestamp: meta.CreationTimestamp,
 		Labels:            meta.Labels,
@@ -61,7 +61,7 @@ func TestGetNamespaceCommonFields(t *testing.T) {
 		UID:               meta.UID,
 		Name:              meta.Name,
 		Namespace:         meta.Namespace,
-		ClusterName:       meta.ClusterName,
+		ClusterName:       "",
 		ClusterUID:        "",
 		CreationTimestamp: meta.CreationTimestamp,
 		Labels:            meta.Labels,
@@ -423,4 +423,22 @@ func TestGetCommonVolumeFields(t *testing.T) {
 		GCEPersistentDiskPDName:    v.GCEPersistentDisk.PDName,
 		GCEPersistentDiskPartition: v.GCEPersistentDisk.Partition,
 	}, "Common volume GCE fields should match")
+
+	readOnly := false
+	v = v1.Volume{
+		VolumeSource: v1.VolumeSource{
+			Ephemeral: &v1.EphemeralVolumeSource{
+				VolumeClaimTemplate: &v1.PersistentVolumeClaimTemplate{
+					Spec: v1.PersistentVolumeClaimSpec{
+						AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, GetCommonVolumeFields(v), CommonVolumeFields{
+		VolumeType:                   "ephemeral",
+		ReadOnly:                     &readOnly,
+		EphemeralVolumeClaimTemplate: v.Ephemeral.VolumeClaimTemplate,
+	}, "Common volume ephemeral fields should match")
 }
@@ -33,7 +33,7 @@ func ConfigMapsGenerate(ctx context.Context, queryContext table.QueryContext) ([
 	results := make([]map[string]string, 0)
 
 	for {
-		configmaps, err := k8s.GetClient().CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.TODO(), options)
+		configmaps, err := k8s.GetClient(ctx).CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,253 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultRegistry   = "docker.io"
+	defaultRepository = "library"
+	defaultTag        = "latest"
+)
+
+// Registries that images are expected to be pulled from. All registries are allowed unless configured with SetAllowedRegistries.
+var allowedRegistries []string
+
+// SetAllowedRegistries sets the registries that images are expected to be pulled from. Entries can include a
+// repository prefix, for example gcr.io/project.
+func SetAllowedRegistries(registries []string) {
+	allowedRegistries = make([]string, 0, len(registries))
+	for _, r := range registries {
+		if r = strings.TrimSuffix(strings.TrimSpace(r), "/"); r != "" {
+			allowedRegistries = append(allowedRegistries, r)
+		}
+	}
+}
+
+// imageReference is a container image reference normalized the same way as container runtimes.
+type imageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageReference splits the image into registry, repository, tag and digest. Registry defaults to
+// docker.io and official docker hub images get library/ repository prefix. Tag is empty if it is not specified.
+func parseImageReference(image string) imageReference {
+	ref := imageReference{}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = defaultRegistry
+		ref.Repository = name
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = defaultRegistry
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = defaultRepository + "/" + ref.Repository
+	}
+
+	return ref
+}
+
+// String returns the fully qualified image reference.
+func (ref imageReference) String() string {
+	s := ref.Registry + "/" + ref.Repository
+	if ref.Tag != "" {
+		s += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		s += "@" + ref.Digest
+	}
+	return s
+}
+
+// isLatest returns true if the image is not pinned to a specific version.
+func (ref imageReference) isLatest() bool {
+	return ref.Digest == "" && (ref.Tag == "" || ref.Tag == defaultTag)
+}
+
+func (ref imageReference) isRegistryAllowed() bool {
+	if len(allowedRegistries) == 0 {
+		return true
+	}
+
+	name := ref.Registry + "/" + ref.Repository
+	for _, r := range allowedRegistries {
+		if name == r || strings.HasPrefix(name, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// getImageDigest returns the digest in container status image ID. Image ID can be a digest with or without
+// runtime specific scheme, or an image reference with digest.
+func getImageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if i := strings.Index(imageID, "://"); i >= 0 {
+		return imageID[i+3:]
+	}
+	return imageID
+}
+
+type image struct {
+	ClusterUID      types.UID
+	Image           string
+	Registry        string
+	Repository      string
+	Tag             string
+	Digest          string
+	ImageDigests    []string
+	Pods            int
+	Workloads       int
+	Latest          bool
+	RegistryAllowed bool
+}
+
+// ImageColumns returns kubernetes container image fields as Osquery table columns.
+func ImageColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&image{})
+}
+
+// getContainerImages returns the image of each container in the pod spec by container name.
+func getContainerImages(spec v1.PodSpec) map[string]string {
+	results := make(map[string]string)
+	for _, c := range spec.InitContainers {
+		results[c.Name] = c.Image
+	}
+	for _, c := range spec.Containers {
+		results[c.Name] = c.Image
+	}
+	for _, c := range spec.EphemeralContainers {
+		results[c.Name] = c.Image
+	}
+	return results
+}
+
+// getSpecImages returns the distinct images of all containers in the pod spec.
+func getSpecImages(spec v1.PodSpec) map[string]bool {
+	results := make(map[string]bool)
+	for _, name := range getContainerImages(spec) {
+		results[name] = true
+	}
+	return results
+}
+
+// imageInventory dedupes images by their fully qualified reference.
+type imageInventory struct {
+	images  map[string]*image
+	digests map[string]map[string]bool
+}
+
+func (inv *imageInventory) get(name string) *image {
+	ref := parseImageReference(name)
+	key := ref.String()
+
+	item, ok := inv.images[key]
+	if !ok {
+		item = &image{
+			ClusterUID:      k8s.GetClusterUID(),
+			Image:           key,
+			Registry:        ref.Registry,
+			Repository:      ref.Repository,
+			Tag:             ref.Tag,
+			Digest:          ref.Digest,
+			Latest:          ref.isLatest(),
+			RegistryAllowed: ref.isRegistryAllowed(),
+		}
+		inv.images[key] = item
+		inv.digests[key] = make(map[string]bool)
+	}
+	return item
+}
+
+// addPod adds the images of the pod workload. Image in spec is used instead of status because container runtimes
+// can report a normalized or resolved image in status.
+func (inv *imageInventory) addPod(p workload.Workload) {
+	images := getContainerImages(p.Spec)
+	for name := range getSpecImages(p.Spec) {
+		inv.get(name).Pods++
+	}
+	if p.PodStatus == nil {
+		return
+	}
+
+	for _, statuses := range [][]v1.ContainerStatus{p.PodStatus.InitContainerStatuses, p.PodStatus.ContainerStatuses, p.PodStatus.EphemeralContainerStatuses} {
+		for _, cs := range statuses {
+			if name, ok := images[cs.Name]; ok && cs.ImageID != "" {
+				inv.digests[inv.get(name).Image][getImageDigest(cs.ImageID)] = true
+			}
+		}
+	}
+}
+
+func (inv *imageInventory) addWorkload(w workload.Workload) {
+	for name := range getSpecImages(w.Spec) {
+		inv.get(name).Workloads++
+	}
+}
+
+// ImagesGenerate generates the distinct container images used by kubernetes pods and workloads as Osquery table data.
+// Workloads only include objects with pod templates that are not managed by another workload.
+func ImagesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &imageInventory{images: make(map[string]*image), digests: make(map[string]map[string]bool)}
+	for _, w := range ws {
+		if w.Kind == workload.KindPod {
+			inv.addPod(w)
+		} else if metav1.GetControllerOf(&w.ObjectMeta) == nil {
+			inv.addWorkload(w)
+		}
+	}
+
+	results := make([]map[string]string, 0, len(inv.images))
+	for key, item := range inv.images {
+		for d := range inv.digests[key] {
+			item.ImageDigests = append(item.ImageDigests, d)
+		}
+		sort.Strings(item.ImageDigests)
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
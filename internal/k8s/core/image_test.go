@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+	digest := "sha256:5a3198179f7972028a29dd7fbf71ac7a21e0dbf46c85e8cc2c37e3b6a5ee26a4"
+
+	assert.Equal(t, imageReference{Registry: "docker.io", Repository: "library/nginx"}, parseImageReference("nginx"))
+	assert.Equal(t, imageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.19"}, parseImageReference("nginx:1.19"))
+	assert.Equal(t, imageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "6.0"}, parseImageReference("index.docker.io/bitnami/redis:6.0"))
+	assert.Equal(t, imageReference{Registry: "localhost:5000", Repository: "app"}, parseImageReference("localhost:5000/app"))
+	assert.Equal(t, imageReference{Registry: "gcr.io", Repository: "project/app", Tag: "v1", Digest: digest}, parseImageReference("gcr.io/project/app:v1@"+digest))
+	assert.Equal(t, imageReference{Registry: "localhost", Repository: "app", Digest: digest}, parseImageReference("localhost/app@"+digest))
+
+	assert.Equal(t, "docker.io/library/nginx", parseImageReference("nginx").String())
+	assert.True(t, parseImageReference("nginx").isLatest())
+	assert.True(t, parseImageReference("nginx:latest").isLatest())
+	assert.False(t, parseImageReference("nginx:1.19").isLatest())
+	assert.False(t, parseImageReference("nginx@"+digest).isLatest())
+
+	assert.Equal(t, digest, getImageDigest("docker-pullable://nginx@"+digest))
+	assert.Equal(t, digest, getImageDigest("docker://"+digest))
+	assert.Equal(t, digest, getImageDigest(digest))
+}
+
+func TestImageRegistryAllowed(t *testing.T) {
+	defer SetAllowedRegistries(nil)
+
+	assert.True(t, parseImageReference("nginx").isRegistryAllowed())
+
+	SetAllowedRegistries([]string{"gcr.io/project/", " quay.io"})
+	assert.True(t, parseImageReference("gcr.io/project/app").isRegistryAllowed())
+	assert.True(t, parseImageReference("quay.io/coreos/etcd:v3.4").isRegistryAllowed())
+	assert.False(t, parseImageReference("gcr.io/project2/app").isRegistryAllowed())
+	assert.False(t, parseImageReference("nginx").isRegistryAllowed())
+}
+
+func TestImagesGenerate(t *testing.T) {
+	images, err := ImagesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"cluster_uid":      "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"image":            "docker.io/jaegertracing/jaeger-operator:1.14.0",
+			"image_digests":    "[\"sha256:5a3198179f7972028a29dd7fbf71ac7a21e0dbf46c85e8cc2c37e3b6a5ee26a4\"]",
+			"latest":           "0",
+			"pods":             "1",
+			"registry":         "docker.io",
+			"registry_allowed": "1",
+			"repository":       "jaegertracing/jaeger-operator",
+			"tag":              "1.14.0",
+			"workloads":        "0",
+		},
+	}, images)
+}
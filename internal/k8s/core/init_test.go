@@ -18,8 +18,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
 )
 
 func loadTestResource(name string, v interface{}) {
@@ -69,10 +71,19 @@ func init() {
 	loadTestResource("pod_test.json", pod)
 	secret := &v1.Secret{}
 	loadTestResource("secret_test.json", secret)
+	tlsSecret := &v1.Secret{}
+	loadTestResource("tls_secret_test.json", tlsSecret)
 	sa := &v1.ServiceAccount{}
 	loadTestResource("service_account_test.json", sa)
 	services := &v1.Service{}
 	loadTestResource("services_test.json", services)
 
-	k8s.SetClient(fake.NewSimpleClientset(lr, cm, ep, ns, node, pod, secret, sa, services), types.UID("d7fd8e77-93de-4742-9037-5db9a01e966a"))
+	k8s.SetClient(fake.NewSimpleClientset(lr, cm, ep, ns, node, pod, secret, tlsSecret, sa, services), types.UID("d7fd8e77-93de-4742-9037-5db9a01e966a"))
+
+	scheme := runtime.NewScheme()
+	metav1.AddMetaToScheme(scheme)
+	k8s.SetMetadataClient(metadatafake.NewSimpleMetadataClient(scheme,
+		&metav1.PartialObjectMetadata{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: secret.ObjectMeta},
+		&metav1.PartialObjectMetadata{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: tlsSecret.ObjectMeta},
+	))
 }
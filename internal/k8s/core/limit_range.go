@@ -34,7 +34,7 @@ func LimitRangesGenerate(ctx context.Context, queryContext table.QueryContext) (
 	results := make([]map[string]string, 0)
 
 	for {
-		ranges, err := k8s.GetClient().CoreV1().LimitRanges(metav1.NamespaceAll).List(context.TODO(), options)
+		ranges, err := k8s.GetClient(ctx).CoreV1().LimitRanges(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -22,18 +22,22 @@ func TestLimitRangesGenerate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, []map[string]string{
 		{
-			"cluster_uid":             "d7fd8e77-93de-4742-9037-5db9a01e966a",
-			"creation_timestamp":      "0",
-			"default":                 "{\"cpu\":\"3\"}",
-			"default_request":         "{\"cpu\":\"2\"}",
-			"labels":                  "{\"a\":\"b\"}",
-			"max":                     "{\"cpu\":\"0\"}",
-			"max_limit_request_ratio": "{\"cpu\":\"1\"}",
-			"min":                     "{\"cpu\":\"4\"}",
-			"name":                    "lr1",
-			"namespace":               "n123",
-			"type":                    "Container",
-			"uid":                     "1234",
+			"cluster_uid":                    "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp":             "0",
+			"default":                        "{\"cpu\":\"3\"}",
+			"default_cpu_millicores":         "3000",
+			"default_request":                "{\"cpu\":\"2\"}",
+			"default_request_cpu_millicores": "2000",
+			"labels":                         "{\"a\":\"b\"}",
+			"max":                            "{\"cpu\":\"0\"}",
+			"max_cpu_millicores":             "0",
+			"max_limit_request_ratio":        "{\"cpu\":\"1\"}",
+			"min":                            "{\"cpu\":\"4\"}",
+			"min_cpu_millicores":             "4000",
+			"name":                           "lr1",
+			"namespace":                      "n123",
+			"type":                           "Container",
+			"uid":                            "1234",
 		},
 	}, js)
 }
@@ -18,31 +18,86 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Pod Security Admission namespace label prefix. Each mode has a level label and a version label with -version suffix.
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// PodSecurityAdmissionFields is the Pod Security Standards level and version of each admission mode.
+type PodSecurityAdmissionFields struct {
+	PodSecurityEnforce        string
+	PodSecurityEnforceVersion string
+	PodSecurityAudit          string
+	PodSecurityAuditVersion   string
+	PodSecurityWarn           string
+	PodSecurityWarnVersion    string
+}
+
 type namespace struct {
 	k8s.CommonFields
+	PodSecurityAdmissionFields
 	v1.NamespaceStatus
 }
 
+// getPodSecurityLabel returns the level and version of the mode. Version defaults to latest if only level is set.
+func getPodSecurityLabel(labels map[string]string, mode string) (string, string) {
+	level := labels[podSecurityLabelPrefix+mode]
+	version := labels[podSecurityLabelPrefix+mode+"-version"]
+	if level != "" && version == "" {
+		version = "latest"
+	}
+	return level, version
+}
+
+func getPodSecurityAdmission(labels map[string]string) PodSecurityAdmissionFields {
+	item := PodSecurityAdmissionFields{}
+	item.PodSecurityEnforce, item.PodSecurityEnforceVersion = getPodSecurityLabel(labels, "enforce")
+	item.PodSecurityAudit, item.PodSecurityAuditVersion = getPodSecurityLabel(labels, "audit")
+	item.PodSecurityWarn, item.PodSecurityWarnVersion = getPodSecurityLabel(labels, "warn")
+	return item
+}
+
 // NamespaceColumns returns kubernetes namespace fields as Osquery table columns.
 func NamespaceColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&namespace{})
 }
 
+// ListNamespaces returns all kubernetes namespaces.
+func ListNamespaces(ctx context.Context) ([]v1.Namespace, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Namespace, 0)
+
+	for {
+		namespaces, err := k8s.GetClient(ctx).CoreV1().Namespaces().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, namespaces.Items...)
+
+		if namespaces.Continue == "" {
+			break
+		}
+		options.Continue = namespaces.Continue
+	}
+
+	return results, nil
+}
+
 // NamespacesGenerate generates the kubernetes namespaces as Osquery table data.
 func NamespacesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	options := metav1.ListOptions{}
 	results := make([]map[string]string, 0)
 
 	for {
-		namespaces, err := k8s.GetClient().CoreV1().Namespaces().List(context.TODO(), options)
+		namespaces, err := k8s.GetClient(ctx).CoreV1().Namespaces().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, n := range namespaces.Items {
 			item := &namespace{
-				CommonFields:    k8s.GetCommonFields(n.ObjectMeta),
-				NamespaceStatus: n.Status,
+				CommonFields:               k8s.GetCommonFields(n.ObjectMeta),
+				PodSecurityAdmissionFields: getPodSecurityAdmission(n.Labels),
+				NamespaceStatus:            n.Status,
 			}
 			results = append(results, k8s.ToMap(item))
 		}
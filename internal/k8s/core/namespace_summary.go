@@ -0,0 +1,218 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type namespaceSummary struct {
+	ClusterUID types.UID
+	Name       string
+	UID        types.UID
+	Phase      string
+	PodSecurityAdmissionFields
+	Pods            int
+	Workloads       int
+	Services        int
+	Secrets         int
+	ConfigMaps      int
+	NetworkPolicies int
+	ResourceQuotas  int
+	LimitRanges     int
+}
+
+// NamespaceSummaryColumns returns kubernetes namespace summary fields as Osquery table columns.
+func NamespaceSummaryColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&namespaceSummary{})
+}
+
+// listPage lists one page of objects and returns their namespaces and the continue token.
+type listPage func(ctx context.Context, options metav1.ListOptions) ([]string, string, error)
+
+// countByNamespace returns the number of objects in each namespace.
+func countByNamespace(ctx context.Context, list listPage) (map[string]int, error) {
+	options := metav1.ListOptions{}
+	results := make(map[string]int)
+
+	for {
+		namespaces, next, err := list(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, n := range namespaces {
+			results[n]++
+		}
+
+		if next == "" {
+			break
+		}
+		options.Continue = next
+	}
+
+	return results, nil
+}
+
+func listServicePage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetClient(ctx).CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+// listSecretPage lists only the metadata of secrets so that the secret data is not read to count them.
+func listSecretPage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetMetadataClient(ctx).Resource(v1.SchemeGroupVersion.WithResource("secrets")).Namespace(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+func listConfigMapPage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetClient(ctx).CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+func listNetworkPolicyPage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetClient(ctx).NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+func listResourceQuotaPage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetClient(ctx).CoreV1().ResourceQuotas(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+func listLimitRangePage(ctx context.Context, options metav1.ListOptions) ([]string, string, error) {
+	list, err := k8s.GetClient(ctx).CoreV1().LimitRanges(metav1.NamespaceAll).List(context.TODO(), options)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		results = append(results, item.Namespace)
+	}
+	return results, list.Continue, nil
+}
+
+// countWorkloads returns the number of pods and top level workloads in each namespace. Workloads managed by
+// another workload, like replica sets of deployments or jobs of cron jobs, are not counted.
+func countWorkloads(ws []workload.Workload) (map[string]int, map[string]int) {
+	pods := make(map[string]int)
+	workloads := make(map[string]int)
+	for _, w := range ws {
+		if w.Kind == workload.KindPod {
+			pods[w.ObjectMeta.Namespace]++
+		} else if metav1.GetControllerOf(&w.ObjectMeta) == nil {
+			workloads[w.ObjectMeta.Namespace]++
+		}
+	}
+	return pods, workloads
+}
+
+// NamespaceSummariesGenerate generates the kubernetes namespaces with object counts as Osquery table data.
+func NamespaceSummariesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	namespaces, err := ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, workloads := countWorkloads(ws)
+
+	services, err := countByNamespace(ctx, listServicePage)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := countByNamespace(ctx, listSecretPage)
+	if err != nil {
+		return nil, err
+	}
+	configMaps, err := countByNamespace(ctx, listConfigMapPage)
+	if err != nil {
+		return nil, err
+	}
+	networkPolicies, err := countByNamespace(ctx, listNetworkPolicyPage)
+	if err != nil {
+		return nil, err
+	}
+	resourceQuotas, err := countByNamespace(ctx, listResourceQuotaPage)
+	if err != nil {
+		return nil, err
+	}
+	limitRanges, err := countByNamespace(ctx, listLimitRangePage)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0, len(namespaces))
+	for _, n := range namespaces {
+		item := &namespaceSummary{
+			ClusterUID:                 k8s.GetClusterUID(),
+			Name:                       n.Name,
+			UID:                        n.UID,
+			Phase:                      string(n.Status.Phase),
+			PodSecurityAdmissionFields: getPodSecurityAdmission(n.Labels),
+			Pods:                       pods[n.Name],
+			Workloads:                  workloads[n.Name],
+			Services:                   services[n.Name],
+			Secrets:                    secrets[n.Name],
+			ConfigMaps:                 configMaps[n.Name],
+			NetworkPolicies:            networkPolicies[n.Name],
+			ResourceQuotas:             resourceQuotas[n.Name],
+			LimitRanges:                limitRanges[n.Name],
+		}
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
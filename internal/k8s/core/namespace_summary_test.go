@@ -0,0 +1,77 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceSummariesGenerate(t *testing.T) {
+	nss, err := NamespaceSummariesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Len(t, nss, 7)
+
+	for _, ns := range nss {
+		if ns["name"] != "default" {
+			assert.Equal(t, "0", ns["pods"])
+			continue
+		}
+		assert.Equal(t, map[string]string{
+			"cluster_uid":                  "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"config_maps":                  "1",
+			"limit_ranges":                 "0",
+			"name":                         "default",
+			"network_policies":             "0",
+			"phase":                        "Active",
+			"pod_security_enforce":         "baseline",
+			"pod_security_enforce_version": "v1.21",
+			"pod_security_warn":            "restricted",
+			"pod_security_warn_version":    "latest",
+			"pods":                         "1",
+			"resource_quotas":              "0",
+			"secrets":                      "2",
+			"services":                     "1",
+			"uid":                          "7b50dc9c-6149-4cac-a0d0-52bf0fa5356d",
+			"workloads":                    "0",
+		}, ns)
+	}
+}
+
+func TestCountWorkloads(t *testing.T) {
+	controller := true
+	owned := func(kind, namespace, name, owner string) workload.Workload {
+		return workload.Workload{
+			Kind: kind,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{{Name: owner, Controller: &controller}},
+			},
+		}
+	}
+
+	pods, workloads := countWorkloads([]workload.Workload{
+		{Kind: workload.KindDeployment, ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "n1"}},
+		owned(workload.KindReplicaSet, "n1", "web-1", "web"),
+		owned(workload.KindPod, "n1", "web-1-a", "web-1"),
+		{Kind: workload.KindCronJob, ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "n2"}},
+		owned(workload.KindJob, "n2", "backup-1", "backup"),
+		{Kind: workload.KindJob, ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "n2"}},
+		{Kind: workload.KindPod, ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "n2"}},
+	})
+	assert.Equal(t, map[string]int{"n1": 1, "n2": 1}, pods)
+	assert.Equal(t, map[string]int{"n1": 1, "n2": 2}, workloads)
+}
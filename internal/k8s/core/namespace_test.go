@@ -22,11 +22,16 @@ func TestNamespacesGenerate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, []map[string]string{
 		{
-			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
-			"creation_timestamp": "1610476216",
-			"name":               "default",
-			"phase":              "Active",
-			"uid":                "7b50dc9c-6149-4cac-a0d0-52bf0fa5356d",
+			"cluster_uid":                  "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp":           "1610476216",
+			"labels":                       "{\"pod-security.kubernetes.io/enforce\":\"baseline\",\"pod-security.kubernetes.io/enforce-version\":\"v1.21\",\"pod-security.kubernetes.io/warn\":\"restricted\"}",
+			"name":                         "default",
+			"phase":                        "Active",
+			"pod_security_enforce":         "baseline",
+			"pod_security_enforce_version": "v1.21",
+			"pod_security_warn":            "restricted",
+			"pod_security_warn_version":    "latest",
+			"uid":                          "7b50dc9c-6149-4cac-a0d0-52bf0fa5356d",
 		},
 		{
 			"annotations":        "{\"kubectl.kubernetes.io/last-applied-configuration\":\"{\\\"apiVersion\\\":\\\"v1\\\",\\\"kind\\\":\\\"Namespace\\\",\\\"metadata\\\":{\\\"annotations\\\":{},\\\"name\\\":\\\"ingress\\\"}}\\n\"}",
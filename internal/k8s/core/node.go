@@ -11,11 +11,13 @@ package core
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Uptycs/kubequery/internal/k8s"
 	"github.com/kolide/osquery-go/plugin/table"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type node struct {
@@ -35,7 +37,7 @@ func NodesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[
 	results := make([]map[string]string, 0)
 
 	for {
-		nodes, err := k8s.GetClient().CoreV1().Nodes().List(context.TODO(), options)
+		nodes, err := k8s.GetClient(ctx).CoreV1().Nodes().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -57,3 +59,161 @@ func NodesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[
 
 	return results, nil
 }
+
+// ListNodes returns all kubernetes nodes.
+func ListNodes(ctx context.Context) ([]v1.Node, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Node, 0)
+
+	for {
+		nodes, err := k8s.GetClient(ctx).CoreV1().Nodes().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, nodes.Items...)
+
+		if nodes.Continue == "" {
+			break
+		}
+		options.Continue = nodes.Continue
+	}
+
+	return results, nil
+}
+
+type nodeImage struct {
+	ClusterUID types.UID
+	NodeName   string
+	NodeUID    types.UID
+	Name       string
+	Digest     string
+	SizeBytes  int64
+}
+
+// NodeImageColumns returns kubernetes node image fields as Osquery table columns.
+func NodeImageColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&nodeImage{})
+}
+
+// getNodeImages returns one row per image name. Names with digest only set the digest column
+// unless the image has no other names.
+func getNodeImages(n v1.Node) []*nodeImage {
+	results := make([]*nodeImage, 0)
+	for _, image := range n.Status.Images {
+		digest := ""
+		names := make([]string, 0, len(image.Names))
+		for _, name := range image.Names {
+			if i := strings.LastIndex(name, "@"); i >= 0 {
+				digest = name[i+1:]
+			} else {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			names = append(names, image.Names...)
+		}
+		if len(names) == 0 {
+			names = append(names, "")
+		}
+
+		for _, name := range names {
+			results = append(results, &nodeImage{
+				ClusterUID: k8s.GetClusterUID(),
+				NodeName:   n.Name,
+				NodeUID:    n.UID,
+				Name:       name,
+				Digest:     digest,
+				SizeBytes:  image.SizeBytes,
+			})
+		}
+	}
+	return results
+}
+
+// NodeImagesGenerate generates the container images cached on kubernetes nodes as Osquery table data.
+func NodeImagesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	nodes, err := ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, n := range nodes {
+		for _, item := range getNodeImages(n) {
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results, nil
+}
+
+type nodeCondition struct {
+	ClusterUID types.UID
+	NodeName   string
+	NodeUID    types.UID
+	v1.NodeCondition
+}
+
+// NodeConditionColumns returns kubernetes node condition fields as Osquery table columns.
+func NodeConditionColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&nodeCondition{})
+}
+
+// NodeConditionsGenerate generates the kubernetes node conditions as Osquery table data.
+func NodeConditionsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	nodes, err := ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, n := range nodes {
+		for _, c := range n.Status.Conditions {
+			item := &nodeCondition{
+				ClusterUID:    k8s.GetClusterUID(),
+				NodeName:      n.Name,
+				NodeUID:       n.UID,
+				NodeCondition: c,
+			}
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results, nil
+}
+
+type nodeAddress struct {
+	ClusterUID types.UID
+	NodeName   string
+	NodeUID    types.UID
+	v1.NodeAddress
+}
+
+// NodeAddressColumns returns kubernetes node address fields as Osquery table columns.
+func NodeAddressColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&nodeAddress{})
+}
+
+// NodeAddressesGenerate generates the kubernetes node addresses as Osquery table data.
+func NodeAddressesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	nodes, err := ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, n := range nodes {
+		for _, a := range n.Status.Addresses {
+			item := &nodeAddress{
+				ClusterUID:  k8s.GetClusterUID(),
+				NodeName:    n.Name,
+				NodeUID:     n.UID,
+				NodeAddress: a,
+			}
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results, nil
+}
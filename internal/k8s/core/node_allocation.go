@@ -0,0 +1,208 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// gpuResources are the extended resource names of GPU device plugins.
+var gpuResources = []v1.ResourceName{"nvidia.com/gpu", "amd.com/gpu"}
+
+type nodeAllocation struct {
+	ClusterUID types.UID
+	NodeName   string
+	NodeUID    types.UID
+
+	CPUAllocatableMillicores int64
+	CPURequestsMillicores    int64
+	CPURequestsPercent       float64
+	CPULimitsMillicores      int64
+	CPULimitsPercent         float64
+
+	MemoryAllocatableBytes int64
+	MemoryRequestsBytes    int64
+	MemoryRequestsPercent  float64
+	MemoryLimitsBytes      int64
+	MemoryLimitsPercent    float64
+
+	EphemeralStorageAllocatableBytes int64
+	EphemeralStorageRequestsBytes    int64
+	EphemeralStorageRequestsPercent  float64
+	EphemeralStorageLimitsBytes      int64
+	EphemeralStorageLimitsPercent    float64
+
+	GPUAllocatable     int64
+	GPURequests        int64
+	GPURequestsPercent float64
+	GPULimits          int64
+	GPULimitsPercent   float64
+
+	Pods        int
+	MaxPods     int64
+	PodsPercent float64
+}
+
+// NodeAllocationColumns returns kubernetes node allocated resource fields as Osquery table columns.
+func NodeAllocationColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&nodeAllocation{})
+}
+
+func addResourceList(to, from v1.ResourceList) {
+	for name, q := range from {
+		if value, ok := to[name]; ok {
+			value.Add(q)
+			to[name] = value
+		} else {
+			to[name] = q.DeepCopy()
+		}
+	}
+}
+
+func maxResourceList(to, from v1.ResourceList) {
+	for name, q := range from {
+		if value, ok := to[name]; !ok || q.Cmp(value) > 0 {
+			to[name] = q.DeepCopy()
+		}
+	}
+}
+
+// getPodRequestsAndLimits returns the effective requests and limits of the pod the same way as scheduler.
+// Init containers run one at a time, so the largest init container is compared to the sum of containers.
+func getPodRequestsAndLimits(spec v1.PodSpec) (v1.ResourceList, v1.ResourceList) {
+	requests := v1.ResourceList{}
+	limits := v1.ResourceList{}
+	for _, c := range spec.Containers {
+		addResourceList(requests, c.Resources.Requests)
+		addResourceList(limits, c.Resources.Limits)
+	}
+	for _, c := range spec.InitContainers {
+		maxResourceList(requests, c.Resources.Requests)
+		maxResourceList(limits, c.Resources.Limits)
+	}
+
+	// Overhead is only added to limits that are set
+	addResourceList(requests, spec.Overhead)
+	for name, q := range spec.Overhead {
+		if value, ok := limits[name]; ok {
+			value.Add(q)
+			limits[name] = value
+		}
+	}
+
+	return requests, limits
+}
+
+func getGPUQuantity(list v1.ResourceList) resource.Quantity {
+	total := resource.Quantity{}
+	for _, name := range gpuResources {
+		if q, ok := list[name]; ok {
+			total.Add(q)
+		}
+	}
+	return total
+}
+
+func percent(value, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(total)
+}
+
+// getNodeAllocation returns the resources allocated to the pods scheduled on the node.
+func getNodeAllocation(n v1.Node, pods []v1.Pod) *nodeAllocation {
+	requests := v1.ResourceList{}
+	limits := v1.ResourceList{}
+	count := 0
+	for _, p := range pods {
+		if p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+			continue
+		}
+		r, l := getPodRequestsAndLimits(p.Spec)
+		addResourceList(requests, r)
+		addResourceList(limits, l)
+		count++
+	}
+
+	allocatable := n.Status.Allocatable
+	item := &nodeAllocation{
+		ClusterUID: k8s.GetClusterUID(),
+		NodeName:   n.Name,
+		NodeUID:    n.UID,
+
+		CPUAllocatableMillicores: allocatable.Cpu().MilliValue(),
+		CPURequestsMillicores:    requests.Cpu().MilliValue(),
+		CPULimitsMillicores:      limits.Cpu().MilliValue(),
+
+		MemoryAllocatableBytes: allocatable.Memory().Value(),
+		MemoryRequestsBytes:    requests.Memory().Value(),
+		MemoryLimitsBytes:      limits.Memory().Value(),
+
+		EphemeralStorageAllocatableBytes: allocatable.StorageEphemeral().Value(),
+		EphemeralStorageRequestsBytes:    requests.StorageEphemeral().Value(),
+		EphemeralStorageLimitsBytes:      limits.StorageEphemeral().Value(),
+
+		Pods:    count,
+		MaxPods: allocatable.Pods().Value(),
+	}
+
+	gpuAllocatable := getGPUQuantity(allocatable)
+	gpuRequests := getGPUQuantity(requests)
+	gpuLimits := getGPUQuantity(limits)
+	item.GPUAllocatable = gpuAllocatable.Value()
+	item.GPURequests = gpuRequests.Value()
+	item.GPULimits = gpuLimits.Value()
+
+	item.CPURequestsPercent = percent(item.CPURequestsMillicores, item.CPUAllocatableMillicores)
+	item.CPULimitsPercent = percent(item.CPULimitsMillicores, item.CPUAllocatableMillicores)
+	item.MemoryRequestsPercent = percent(item.MemoryRequestsBytes, item.MemoryAllocatableBytes)
+	item.MemoryLimitsPercent = percent(item.MemoryLimitsBytes, item.MemoryAllocatableBytes)
+	item.EphemeralStorageRequestsPercent = percent(item.EphemeralStorageRequestsBytes, item.EphemeralStorageAllocatableBytes)
+	item.EphemeralStorageLimitsPercent = percent(item.EphemeralStorageLimitsBytes, item.EphemeralStorageAllocatableBytes)
+	item.GPURequestsPercent = percent(item.GPURequests, item.GPUAllocatable)
+	item.GPULimitsPercent = percent(item.GPULimits, item.GPUAllocatable)
+	item.PodsPercent = percent(int64(item.Pods), item.MaxPods)
+
+	return item
+}
+
+// NodeAllocationsGenerate generates the resources requested by non-terminated pods on each kubernetes node as Osquery table data.
+func NodeAllocationsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	nodes, err := ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := workload.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePods := make(map[string][]v1.Pod)
+	for _, p := range pods {
+		if p.Spec.NodeName != "" {
+			nodePods[p.Spec.NodeName] = append(nodePods[p.Spec.NodeName], p)
+		}
+	}
+
+	results := make([]map[string]string, 0, len(nodes))
+	for _, n := range nodes {
+		results = append(results, k8s.ToMap(getNodeAllocation(n, nodePods[n.Name])))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,132 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func resources(requests, limits v1.ResourceList) v1.ResourceRequirements {
+	return v1.ResourceRequirements{Requests: requests, Limits: limits}
+}
+
+func TestGetPodRequestsAndLimits(t *testing.T) {
+	requests, limits := getPodRequestsAndLimits(v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Resources: resources(v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}, nil)},
+			{Resources: resources(v1.ResourceList{v1.ResourceMemory: resource.MustParse("10Mi")}, nil)},
+		},
+		Containers: []v1.Container{
+			{Resources: resources(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			)},
+			{Resources: resources(v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")}, nil)},
+		},
+		Overhead: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("20Mi")},
+	})
+
+	assert.Equal(t, int64(2100), requests.Cpu().MilliValue())
+	assert.Equal(t, int64(120*1024*1024), requests.Memory().Value())
+	assert.Equal(t, int64(1100), limits.Cpu().MilliValue())
+	assert.True(t, limits.Memory().IsZero())
+}
+
+func TestGetNodeAllocation(t *testing.T) {
+	n := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1", UID: "n123"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+				v1.ResourcePods:   resource.MustParse("10"),
+				"nvidia.com/gpu":  resource.MustParse("2"),
+			},
+		},
+	}
+	pod := func(phase v1.PodPhase, cpu, memory, gpu string) v1.Pod {
+		return v1.Pod{
+			Spec: v1.PodSpec{Containers: []v1.Container{{Resources: resources(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory), "nvidia.com/gpu": resource.MustParse(gpu)},
+				v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory), "nvidia.com/gpu": resource.MustParse(gpu)},
+			)}}},
+			Status: v1.PodStatus{Phase: phase},
+		}
+	}
+
+	item := getNodeAllocation(n, []v1.Pod{
+		pod(v1.PodRunning, "1", "2Gi", "1"),
+		pod(v1.PodPending, "500m", "2Gi", "0"),
+		pod(v1.PodSucceeded, "2", "4Gi", "1"),
+	})
+	item.ClusterUID = ""
+	assert.Equal(t, &nodeAllocation{
+		NodeName:                 "n1",
+		NodeUID:                  "n123",
+		CPUAllocatableMillicores: 4000,
+		CPURequestsMillicores:    1500,
+		CPURequestsPercent:       37.5,
+		MemoryAllocatableBytes:   8 * 1024 * 1024 * 1024,
+		MemoryRequestsBytes:      4 * 1024 * 1024 * 1024,
+		MemoryRequestsPercent:    50,
+		MemoryLimitsBytes:        4 * 1024 * 1024 * 1024,
+		MemoryLimitsPercent:      50,
+		GPUAllocatable:           2,
+		GPURequests:              1,
+		GPURequestsPercent:       50,
+		GPULimits:                1,
+		GPULimitsPercent:         50,
+		Pods:                     2,
+		MaxPods:                  10,
+		PodsPercent:              20,
+	}, item)
+}
+
+func TestNodeAllocationsGenerate(t *testing.T) {
+	allocations, err := NodeAllocationsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"cluster_uid":                         "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"cpu_allocatable_millicores":          "12000",
+			"cpu_limits_millicores":               "0",
+			"cpu_limits_percent":                  "0.000000",
+			"cpu_requests_millicores":             "0",
+			"cpu_requests_percent":                "0.000000",
+			"ephemeral_storage_allocatable_bytes": "981147418624",
+			"ephemeral_storage_limits_bytes":      "0",
+			"ephemeral_storage_limits_percent":    "0.000000",
+			"ephemeral_storage_requests_bytes":    "0",
+			"ephemeral_storage_requests_percent":  "0.000000",
+			"gpu_allocatable":                     "0",
+			"gpu_limits":                          "0",
+			"gpu_limits_percent":                  "0.000000",
+			"gpu_requests":                        "0",
+			"gpu_requests_percent":                "0.000000",
+			"max_pods":                            "110",
+			"memory_allocatable_bytes":            "33189625856",
+			"memory_limits_bytes":                 "0",
+			"memory_limits_percent":               "0.000000",
+			"memory_requests_bytes":               "0",
+			"memory_requests_percent":             "0.000000",
+			"node_name":                           "seshu",
+			"node_uid":                            "d0d45111-421d-4d4f-89c9-3e75ca2dc06c",
+			"pods":                                "1",
+			"pods_percent":                        "0.909091",
+		},
+	}, allocations)
+}
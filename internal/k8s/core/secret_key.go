@@ -0,0 +1,126 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Salt prepended to secret values before hashing. Random unless configured with SetSecretKeySalt.
+var secretKeySalt []byte
+
+func init() {
+	secretKeySalt = make([]byte, 32)
+	if _, err := rand.Read(secretKeySalt); err != nil {
+		panic(err)
+	}
+}
+
+// SetSecretKeySalt sets the salt used to fingerprint secret values. Without a configured salt,
+// fingerprints are random per process and can only be compared within the same process lifetime.
+func SetSecretKeySalt(salt string) {
+	secretKeySalt = []byte(salt)
+}
+
+func fingerprintSecretValue(value []byte) string {
+	h := sha256.New()
+	h.Write(secretKeySalt)
+	h.Write(value)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type secretKey struct {
+	k8s.CommonNamespacedFields
+	k8s.CertificateFields
+	SecretName  string
+	Type        v1.SecretType
+	Source      string
+	Length      int
+	Fingerprint string
+}
+
+// SecretKeyColumns returns kubernetes secret key fields as Osquery table columns.
+func SecretKeyColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&secretKey{})
+}
+
+func createSecretKey(s v1.Secret, key, source string, value []byte) *secretKey {
+	item := &secretKey{
+		CommonNamespacedFields: k8s.GetCommonNamespacedFields(s.ObjectMeta),
+		SecretName:             s.Name,
+		Type:                   s.Type,
+		Source:                 source,
+		Length:                 len(value),
+		Fingerprint:            fingerprintSecretValue(value),
+	}
+	item.Name = key
+
+	if s.Type == v1.SecretTypeTLS {
+		if certs := k8s.ParseCertificates(value); len(certs) > 0 {
+			item.CertificateFields = k8s.GetCertificateFields(certs[0])
+		}
+	}
+	return item
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SecretKeysGenerate generates one row per kubernetes secret key as Osquery table data.
+// Secret values are never returned. Only the length and a salted SHA-256 fingerprint of the value are reported.
+func SecretKeysGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+	results := make([]map[string]string, 0)
+
+	for {
+		secrets, err := k8s.GetClient(ctx).CoreV1().Secrets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range secrets.Items {
+			for _, k := range sortedKeys(s.Data) {
+				item := createSecretKey(s, k, "data", s.Data[k])
+				results = append(results, k8s.ToMap(item))
+			}
+
+			stringData := make(map[string][]byte, len(s.StringData))
+			for k, v := range s.StringData {
+				stringData[k] = []byte(v)
+			}
+			for _, k := range sortedKeys(stringData) {
+				item := createSecretKey(s, k, "string_data", stringData[k])
+				results = append(results, k8s.ToMap(item))
+			}
+		}
+
+		if secrets.Continue == "" {
+			break
+		}
+		options.Continue = secrets.Continue
+	}
+
+	return results, nil
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretKeysGenerate(t *testing.T) {
+	SetSecretKeySalt("salt")
+	sks, err := SecretKeysGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"annotations":        "{\"istio.io/service-account.name\":\"jaeger-operator\"}",
+			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp": "1611191305",
+			"fingerprint":        "ccae88a88516cf8df19c28b3b88f8fdeef479830dba9b6b68efced7eef2e059e",
+			"length":             "1155",
+			"name":               "cert-chain.pem",
+			"namespace":          "default",
+			"secret_name":        "istio.jaeger-operator",
+			"source":             "data",
+			"type":               "istio.io/key-and-cert",
+			"uid":                "fb60f655-6b24-4f35-8e2d-17d7ca3ba7d4",
+		},
+		{
+			"annotations":        "{\"istio.io/service-account.name\":\"jaeger-operator\"}",
+			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp": "1611191305",
+			"fingerprint":        "203e4d7874f56709f0d98a71262ca3f022a18ff8ed6c30f0f711f8d9883d3866",
+			"length":             "1054",
+			"name":               "root-cert.pem",
+			"namespace":          "default",
+			"secret_name":        "istio.jaeger-operator",
+			"source":             "data",
+			"type":               "istio.io/key-and-cert",
+			"uid":                "fb60f655-6b24-4f35-8e2d-17d7ca3ba7d4",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp":               "1611191400",
+			"fingerprint":                      "203e4d7874f56709f0d98a71262ca3f022a18ff8ed6c30f0f711f8d9883d3866",
+			"length":                           "1054",
+			"name":                             "tls.crt",
+			"namespace":                        "default",
+			"secret_name":                      "ingress-tls",
+			"source":                           "data",
+			"type":                             "kubernetes.io/tls",
+			"uid":                              "5a1e2a3c-57e9-4d8f-8c63-1f0e2b6d7c11",
+		},
+		{
+			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp": "1611191400",
+			"fingerprint":        "63479ad69a090b258277ec8fba6f99419a2ffb248981510657c944ccd1148e97",
+			"length":             "0",
+			"name":               "tls.key",
+			"namespace":          "default",
+			"secret_name":        "ingress-tls",
+			"source":             "data",
+			"type":               "kubernetes.io/tls",
+			"uid":                "5a1e2a3c-57e9-4d8f-8c63-1f0e2b6d7c11",
+		},
+	}, sks)
+}
@@ -20,7 +20,7 @@ import (
 func TestSecretsGenerate(t *testing.T) {
 	ss, err := SecretsGenerate(context.TODO(), table.QueryContext{})
 	assert.Nil(t, err)
-	assert.Equal(t, []map[string]string{
+	assert.ElementsMatch(t, []map[string]string{
 		{
 			"annotations":        "{\"istio.io/service-account.name\":\"jaeger-operator\"}",
 			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
@@ -30,5 +30,13 @@ func TestSecretsGenerate(t *testing.T) {
 			"type":               "istio.io/key-and-cert",
 			"uid":                "fb60f655-6b24-4f35-8e2d-17d7ca3ba7d4",
 		},
+		{
+			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp": "1611191400",
+			"name":               "ingress-tls",
+			"namespace":          "default",
+			"type":               "kubernetes.io/tls",
+			"uid":                "5a1e2a3c-57e9-4d8f-8c63-1f0e2b6d7c11",
+		},
 	}, ss)
 }
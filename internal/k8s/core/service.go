@@ -13,9 +13,12 @@ import (
 	"context"
 
 	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
 	"github.com/kolide/osquery-go/plugin/table"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type service struct {
@@ -29,18 +32,45 @@ func ServiceColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&service{})
 }
 
-// ServicesGenerate generates the kubernetes services as Osquery table data.
-func ServicesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+// listServicePages calls fn with each page of kubernetes services in all namespaces.
+func listServicePages(ctx context.Context, fn func(services []v1.Service)) error {
 	options := metav1.ListOptions{}
-	results := make([]map[string]string, 0)
 
 	for {
-		services, err := k8s.GetClient().CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), options)
+		services, err := k8s.GetClient(ctx).CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		fn(services.Items)
+
+		if services.Continue == "" {
+			return nil
 		}
+		options.Continue = services.Continue
+	}
+}
+
+// ListServices returns all kubernetes services in all namespaces.
+func ListServices(ctx context.Context) ([]v1.Service, error) {
+	results := make([]v1.Service, 0)
+
+	err := listServicePages(ctx, func(services []v1.Service) {
+		results = append(results, services...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
 
-		for _, s := range services.Items {
+// ServicesGenerate generates the kubernetes services as Osquery table data. Services are converted one page at a time.
+func ServicesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	err := listServicePages(ctx, func(services []v1.Service) {
+		for _, s := range services {
 			item := &service{
 				CommonNamespacedFields: k8s.GetCommonNamespacedFields(s.ObjectMeta),
 				ServiceSpec:            s.Spec,
@@ -48,11 +78,120 @@ func ServicesGenerate(ctx context.Context, queryContext table.QueryContext) ([]m
 			}
 			results = append(results, k8s.ToMap(item))
 		}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if services.Continue == "" {
-			break
+	return results, nil
+}
+
+type servicePort struct {
+	k8s.CommonNamespacedFields
+	ServiceName string
+	ServiceType v1.ServiceType
+	Protocol    v1.Protocol
+	AppProtocol *string
+	Port        int32
+	TargetPort  string
+	NodePort    int32
+}
+
+// ServicePortColumns returns kubernetes service port fields as Osquery table columns.
+func ServicePortColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&servicePort{})
+}
+
+// ServicePortsGenerate generates one row per kubernetes service port as Osquery table data.
+func ServicePortsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	services, err := ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range services {
+		for _, p := range s.Spec.Ports {
+			item := &servicePort{
+				CommonNamespacedFields: k8s.GetCommonNamespacedFields(s.ObjectMeta),
+				ServiceName:            s.Name,
+				ServiceType:            s.Spec.Type,
+				Protocol:               p.Protocol,
+				AppProtocol:            p.AppProtocol,
+				Port:                   p.Port,
+				TargetPort:             p.TargetPort.String(),
+				NodePort:               p.NodePort,
+			}
+			item.Name = p.Name
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results, nil
+}
+
+type servicePod struct {
+	ClusterUID  types.UID
+	Namespace   string
+	ServiceName string
+	ServiceUID  types.UID
+	PodName     string
+	PodUID      types.UID
+	PodIP       string
+	PodPhase    v1.PodPhase
+	NodeName    string
+}
+
+// ServicePodColumns returns kubernetes service to pod mapping fields as Osquery table columns.
+func ServicePodColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&servicePod{})
+}
+
+// ServicePodsGenerate generates kubernetes service to pod mapping as Osquery table data.
+// Service selector is evaluated against labels of the pods in the same namespace.
+// Services without selector are not mapped to any pods.
+func ServicePodsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	services, err := ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := workload.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	podsByNamespace := make(map[string][]v1.Pod)
+	for _, p := range pods {
+		podsByNamespace[p.Namespace] = append(podsByNamespace[p.Namespace], p)
+	}
+
+	for _, s := range services {
+		if len(s.Spec.Selector) == 0 {
+			continue
+		}
+
+		selector := labels.SelectorFromSet(s.Spec.Selector)
+		for _, p := range podsByNamespace[s.Namespace] {
+			if !selector.Matches(labels.Set(p.Labels)) {
+				continue
+			}
+
+			item := &servicePod{
+				ClusterUID:  k8s.GetClusterUID(),
+				Namespace:   s.Namespace,
+				ServiceName: s.Name,
+				ServiceUID:  s.UID,
+				PodName:     p.Name,
+				PodUID:      p.UID,
+				PodIP:       p.Status.PodIP,
+				PodPhase:    p.Status.Phase,
+				NodeName:    p.Spec.NodeName,
+			}
+			results = append(results, k8s.ToMap(item))
 		}
-		options.Continue = services.Continue
 	}
 
 	return results, nil
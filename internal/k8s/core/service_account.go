@@ -30,13 +30,35 @@ func ServiceAccountColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&serviceAccount{})
 }
 
+// ListServiceAccounts returns all kubernetes service accounts in all namespaces.
+func ListServiceAccounts(ctx context.Context) ([]v1.ServiceAccount, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.ServiceAccount, 0)
+
+	for {
+		sas, err := k8s.GetClient(ctx).CoreV1().ServiceAccounts(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, sas.Items...)
+
+		if sas.Continue == "" {
+			break
+		}
+		options.Continue = sas.Continue
+	}
+
+	return results, nil
+}
+
 // ServiceAccountsGenerate generates the kubernetes service accounts as Osquery table data.
 func ServiceAccountsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	options := metav1.ListOptions{}
 	results := make([]map[string]string, 0)
 
 	for {
-		sas, err := k8s.GetClient().CoreV1().ServiceAccounts(metav1.NamespaceAll).List(context.TODO(), options)
+		sas, err := k8s.GetClient(ctx).CoreV1().ServiceAccounts(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
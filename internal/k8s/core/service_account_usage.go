@@ -0,0 +1,215 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// serviceAccountTokenPath is where service account admission controller mounts the token.
+const serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+type projectedToken struct {
+	Volume            string   `json:"volume"`
+	Path              string   `json:"path"`
+	Audience          string   `json:"audience,omitempty"`
+	ExpirationSeconds *int64   `json:"expirationSeconds,omitempty"`
+	Containers        []string `json:"containers,omitempty"`
+}
+
+type serviceAccountUsage struct {
+	ClusterUID                   types.UID
+	Namespace                    string
+	PodName                      string
+	PodUID                       types.UID
+	ServiceAccountName           string
+	ServiceAccountUID            types.UID
+	PodAutomountToken            *bool
+	ServiceAccountAutomountToken *bool
+	AutomountToken               bool
+	TokenMounted                 bool
+	ProjectedTokens              []projectedToken
+	LegacyTokenSecrets           []string
+	MountedLegacyTokenSecrets    []string
+}
+
+// ServiceAccountUsageColumns returns kubernetes service account usage fields as Osquery table columns.
+func ServiceAccountUsageColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&serviceAccountUsage{})
+}
+
+func listServiceAccountTokenSecrets(ctx context.Context) (map[string]v1.Secret, error) {
+	options := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", string(v1.SecretTypeServiceAccountToken)).String(),
+	}
+	results := make(map[string]v1.Secret)
+
+	for {
+		secrets, err := k8s.GetClient(ctx).CoreV1().Secrets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range secrets.Items {
+			if s.Type == v1.SecretTypeServiceAccountToken {
+				results[s.Namespace+"/"+s.Name] = s
+			}
+		}
+
+		if secrets.Continue == "" {
+			break
+		}
+		options.Continue = secrets.Continue
+	}
+
+	return results, nil
+}
+
+// getVolumeContainers returns the names of all containers that mount each volume and the volumes mounted at token path.
+func getVolumeContainers(spec v1.PodSpec) (map[string][]string, map[string]bool) {
+	containers := make(map[string][]string)
+	tokenPath := make(map[string]bool)
+	add := func(name string, mounts []v1.VolumeMount) {
+		for _, m := range mounts {
+			containers[m.Name] = append(containers[m.Name], name)
+			if m.MountPath == serviceAccountTokenPath {
+				tokenPath[m.Name] = true
+			}
+		}
+	}
+
+	for _, c := range spec.InitContainers {
+		add(c.Name, c.VolumeMounts)
+	}
+	for _, c := range spec.Containers {
+		add(c.Name, c.VolumeMounts)
+	}
+	for _, c := range spec.EphemeralContainers {
+		add(c.Name, c.VolumeMounts)
+	}
+	return containers, tokenPath
+}
+
+// getServiceAccountName returns the effective service account name of the pod.
+func getServiceAccountName(spec v1.PodSpec) string {
+	if spec.ServiceAccountName != "" {
+		return spec.ServiceAccountName
+	}
+	if spec.DeprecatedServiceAccount != "" {
+		return spec.DeprecatedServiceAccount
+	}
+	return "default"
+}
+
+// getServiceAccountUsage returns the effective service account of the pod and the tokens that are mounted.
+// Service account can be nil if it does not exist. Token secrets are keyed by namespace/name.
+func getServiceAccountUsage(p v1.Pod, sa *v1.ServiceAccount, tokenSecrets map[string]v1.Secret) *serviceAccountUsage {
+	item := &serviceAccountUsage{
+		ClusterUID:         k8s.GetClusterUID(),
+		Namespace:          p.Namespace,
+		PodName:            p.Name,
+		PodUID:             p.UID,
+		ServiceAccountName: getServiceAccountName(p.Spec),
+		PodAutomountToken:  p.Spec.AutomountServiceAccountToken,
+		AutomountToken:     true,
+	}
+
+	legacy := make(map[string]bool)
+	if sa != nil {
+		item.ServiceAccountUID = sa.UID
+		item.ServiceAccountAutomountToken = sa.AutomountServiceAccountToken
+		for _, s := range sa.Secrets {
+			if _, ok := tokenSecrets[sa.Namespace+"/"+s.Name]; ok {
+				legacy[s.Name] = true
+				item.LegacyTokenSecrets = append(item.LegacyTokenSecrets, s.Name)
+			}
+		}
+	}
+
+	// Pod setting takes precedence over service account setting
+	if item.PodAutomountToken != nil {
+		item.AutomountToken = *item.PodAutomountToken
+	} else if item.ServiceAccountAutomountToken != nil {
+		item.AutomountToken = *item.ServiceAccountAutomountToken
+	}
+
+	containers, tokenPath := getVolumeContainers(p.Spec)
+	for _, v := range p.Spec.Volumes {
+		if len(containers[v.Name]) == 0 {
+			continue
+		}
+
+		if v.Secret != nil {
+			_, ok := tokenSecrets[p.Namespace+"/"+v.Secret.SecretName]
+			if ok || legacy[v.Secret.SecretName] || tokenPath[v.Name] {
+				item.TokenMounted = true
+				item.MountedLegacyTokenSecrets = append(item.MountedLegacyTokenSecrets, v.Secret.SecretName)
+			}
+		}
+
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.ServiceAccountToken == nil {
+					continue
+				}
+				item.TokenMounted = true
+				item.ProjectedTokens = append(item.ProjectedTokens, projectedToken{
+					Volume:            v.Name,
+					Path:              s.ServiceAccountToken.Path,
+					Audience:          s.ServiceAccountToken.Audience,
+					ExpirationSeconds: s.ServiceAccountToken.ExpirationSeconds,
+					Containers:        containers[v.Name],
+				})
+			}
+		}
+	}
+
+	sort.Strings(item.LegacyTokenSecrets)
+	sort.Strings(item.MountedLegacyTokenSecrets)
+	return item
+}
+
+// ServiceAccountUsageGenerate generates the kubernetes pod service accounts and mounted tokens as Osquery table data.
+func ServiceAccountUsageGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	pods, err := workload.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sas, err := ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokenSecrets, err := listServiceAccountTokenSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccounts := make(map[string]*v1.ServiceAccount, len(sas))
+	for i := range sas {
+		serviceAccounts[sas[i].Namespace+"/"+sas[i].Name] = &sas[i]
+	}
+
+	results := make([]map[string]string, 0)
+	for _, p := range pods {
+		sa := serviceAccounts[p.Namespace+"/"+getServiceAccountName(p.Spec)]
+		results = append(results, k8s.ToMap(getServiceAccountUsage(p, sa, tokenSecrets)))
+	}
+
+	return results, nil
+}
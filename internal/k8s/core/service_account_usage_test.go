@@ -0,0 +1,97 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGetServiceAccountUsage(t *testing.T) {
+	f := false
+	expiration := int64(3607)
+	sa := &v1.ServiceAccount{
+		ObjectMeta:                   metav1.ObjectMeta{Name: "app", Namespace: "ns1", UID: types.UID("sa1")},
+		Secrets:                      []v1.ObjectReference{{Name: "app-token-abcde"}, {Name: "app-dockercfg"}},
+		AutomountServiceAccountToken: &f,
+	}
+	tokenSecrets := map[string]v1.Secret{
+		"ns1/app-token-abcde": {ObjectMeta: metav1.ObjectMeta{Name: "app-token-abcde", Namespace: "ns1"}, Type: v1.SecretTypeServiceAccountToken},
+	}
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "ns1", UID: types.UID("p1")},
+		Spec: v1.PodSpec{
+			ServiceAccountName: "app",
+			Containers: []v1.Container{
+				{Name: "app", VolumeMounts: []v1.VolumeMount{{Name: "vault-token", MountPath: "/var/run/vault"}}},
+				{Name: "sidecar", VolumeMounts: []v1.VolumeMount{{Name: "vault-token", MountPath: "/var/run/vault"}}},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name: "vault-token",
+					VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{Sources: []v1.VolumeProjection{
+						{ServiceAccountToken: &v1.ServiceAccountTokenProjection{Audience: "vault", ExpirationSeconds: &expiration, Path: "token"}},
+					}}},
+				},
+				{Name: "unused", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "app-token-abcde"}}},
+			},
+		},
+	}
+
+	item := getServiceAccountUsage(pod, sa, tokenSecrets)
+	assert.Equal(t, types.UID("sa1"), item.ServiceAccountUID)
+	assert.False(t, item.AutomountToken)
+	assert.True(t, item.TokenMounted)
+	assert.Equal(t, []projectedToken{
+		{Volume: "vault-token", Path: "token", Audience: "vault", ExpirationSeconds: &expiration, Containers: []string{"app", "sidecar"}},
+	}, item.ProjectedTokens)
+	assert.Equal(t, []string{"app-token-abcde"}, item.LegacyTokenSecrets)
+	assert.Nil(t, item.MountedLegacyTokenSecrets)
+
+	pod.Spec.AutomountServiceAccountToken = &f
+	pod.Spec.Volumes = pod.Spec.Volumes[1:]
+	pod.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{{Name: "unused", MountPath: "/token"}}
+	item = getServiceAccountUsage(pod, nil, tokenSecrets)
+	assert.Equal(t, types.UID(""), item.ServiceAccountUID)
+	assert.False(t, item.AutomountToken)
+	assert.True(t, item.TokenMounted)
+	assert.Nil(t, item.ProjectedTokens)
+	assert.Nil(t, item.LegacyTokenSecrets)
+	assert.Equal(t, []string{"app-token-abcde"}, item.MountedLegacyTokenSecrets)
+
+	pod.Spec.ServiceAccountName = ""
+	pod.Spec.Containers[0].VolumeMounts = nil
+	item = getServiceAccountUsage(pod, nil, tokenSecrets)
+	assert.Equal(t, "default", item.ServiceAccountName)
+	assert.False(t, item.TokenMounted)
+}
+
+func TestServiceAccountUsageGenerate(t *testing.T) {
+	sau, err := ServiceAccountUsageGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"automount_token":              "1",
+			"cluster_uid":                  "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"mounted_legacy_token_secrets": "[\"jaeger-operator-token-c94jx\"]",
+			"namespace":                    "default",
+			"pod_name":                     "jaeger-operator-5db4f9d996-pm7ld",
+			"pod_uid":                      "2271363b-ffc9-4f00-984c-e0a125ee2d7a",
+			"service_account_name":         "jaeger-operator",
+			"token_mounted":                "1",
+		},
+	}, sau)
+}
@@ -40,3 +40,42 @@ func TestServicesGenerate(t *testing.T) {
 		},
 	}, ss)
 }
+
+func TestServicePortsGenerate(t *testing.T) {
+	sps, err := ServicePortsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"cluster_uid":        "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"creation_timestamp": "1611191332",
+			"labels":             "{\"name\":\"jaeger-operator\"}",
+			"name":               "metrics",
+			"namespace":          "default",
+			"node_port":          "0",
+			"port":               "8383",
+			"protocol":           "TCP",
+			"service_name":       "jaeger-operator",
+			"service_type":       "ClusterIP",
+			"target_port":        "8383",
+			"uid":                "d8dfda88-e2c5-479e-bb2d-d0964805a925",
+		},
+	}, sps)
+}
+
+func TestServicePodsGenerate(t *testing.T) {
+	sps, err := ServicePodsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"cluster_uid":  "d7fd8e77-93de-4742-9037-5db9a01e966a",
+			"namespace":    "default",
+			"node_name":    "seshu",
+			"pod_ip":       "10.1.26.50",
+			"pod_name":     "jaeger-operator-5db4f9d996-pm7ld",
+			"pod_phase":    "Running",
+			"pod_uid":      "2271363b-ffc9-4f00-984c-e0a125ee2d7a",
+			"service_name": "jaeger-operator",
+			"service_uid":  "d8dfda88-e2c5-479e-bb2d-d0964805a925",
+		},
+	}, sps)
+}
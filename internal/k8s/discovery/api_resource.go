@@ -31,7 +31,7 @@ func APIResourceColumns() []table.ColumnDefinition {
 func APIResourcesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	results := make([]map[string]string, 0)
 
-	sr, err := k8s.GetClient().Discovery().ServerResources()
+	sr, err := k8s.GetClient(ctx).Discovery().ServerResources()
 	if err != nil {
 		return nil, err
 	}
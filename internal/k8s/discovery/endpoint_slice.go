@@ -0,0 +1,226 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package discovery
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// convertEndpointSlice converts discovery.k8s.io/v1beta1 endpoint slice to v1. Topology is moved to the zone
+// and node name fields the same way as the API server.
+func convertEndpointSlice(es v1beta1.EndpointSlice) discoveryv1.EndpointSlice {
+	result := discoveryv1.EndpointSlice{
+		ObjectMeta:  es.ObjectMeta,
+		AddressType: discoveryv1.AddressType(es.AddressType),
+	}
+	for _, p := range es.Ports {
+		result.Ports = append(result.Ports, discoveryv1.EndpointPort{
+			Name:        p.Name,
+			Protocol:    p.Protocol,
+			Port:        p.Port,
+			AppProtocol: p.AppProtocol,
+		})
+	}
+	for _, e := range es.Endpoints {
+		endpoint := discoveryv1.Endpoint{
+			Addresses: e.Addresses,
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:       e.Conditions.Ready,
+				Serving:     e.Conditions.Serving,
+				Terminating: e.Conditions.Terminating,
+			},
+			Hostname:           e.Hostname,
+			TargetRef:          e.TargetRef,
+			DeprecatedTopology: e.Topology,
+			NodeName:           e.NodeName,
+		}
+		if zone, ok := e.Topology[v1.LabelTopologyZone]; ok {
+			endpoint.Zone = &zone
+		}
+		if hostname, ok := e.Topology[v1.LabelHostname]; ok && endpoint.NodeName == nil {
+			endpoint.NodeName = &hostname
+		}
+		if e.Hints != nil {
+			endpoint.Hints = &discoveryv1.EndpointHints{}
+			for _, z := range e.Hints.ForZones {
+				endpoint.Hints.ForZones = append(endpoint.Hints.ForZones, discoveryv1.ForZone{Name: z.Name})
+			}
+		}
+		result.Endpoints = append(result.Endpoints, endpoint)
+	}
+	return result
+}
+
+// listEndpointSlicesV1beta1 returns all endpoint slices using discovery.k8s.io/v1beta1 API converted to v1.
+func listEndpointSlicesV1beta1(ctx context.Context) ([]discoveryv1.EndpointSlice, error) {
+	options := metav1.ListOptions{}
+	results := make([]discoveryv1.EndpointSlice, 0)
+
+	for {
+		ess, err := k8s.GetClient(ctx).DiscoveryV1beta1().EndpointSlices(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, es := range ess.Items {
+			results = append(results, convertEndpointSlice(es))
+		}
+
+		if ess.Continue == "" {
+			break
+		}
+		options.Continue = ess.Continue
+	}
+
+	return results, nil
+}
+
+// listEndpointSlices returns all endpoint slices. discovery.k8s.io/v1beta1 API is used if v1 is not served
+// by clusters older than 1.21.
+func listEndpointSlices(ctx context.Context) ([]discoveryv1.EndpointSlice, error) {
+	options := metav1.ListOptions{}
+	results := make([]discoveryv1.EndpointSlice, 0)
+
+	for {
+		ess, err := k8s.GetClient(ctx).DiscoveryV1().EndpointSlices(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return listEndpointSlicesV1beta1(ctx)
+			}
+			return nil, err
+		}
+
+		results = append(results, ess.Items...)
+
+		if ess.Continue == "" {
+			break
+		}
+		options.Continue = ess.Continue
+	}
+
+	return results, nil
+}
+
+type endpointSlice struct {
+	k8s.CommonNamespacedFields
+	ServiceName   string
+	AddressType   discoveryv1.AddressType
+	Ports         []discoveryv1.EndpointPort
+	EndpointCount int
+}
+
+// EndpointSliceColumns returns kubernetes endpoint slice fields as Osquery table columns.
+func EndpointSliceColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&endpointSlice{})
+}
+
+// EndpointSlicesGenerate generates the kubernetes endpoint slices as Osquery table data.
+func EndpointSlicesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	ess, err := listEndpointSlices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0, len(ess))
+	for _, es := range ess {
+		item := &endpointSlice{
+			CommonNamespacedFields: k8s.GetCommonNamespacedFields(es.ObjectMeta),
+			ServiceName:            es.Labels[discoveryv1.LabelServiceName],
+			AddressType:            es.AddressType,
+			Ports:                  es.Ports,
+			EndpointCount:          len(es.Endpoints),
+		}
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
+
+type endpointSliceEndpoint struct {
+	k8s.CommonNamespacedFields
+	ServiceName        string
+	AddressType        discoveryv1.AddressType
+	Address            string
+	Hostname           *string
+	Ready              *bool
+	Serving            *bool
+	Terminating        *bool
+	NodeName           string
+	Zone               string
+	TargetRefKind      string
+	TargetRefNamespace string
+	TargetRefName      string
+	TargetRefUID       types.UID
+}
+
+// EndpointSliceEndpointColumns returns kubernetes endpoint slice endpoint fields as Osquery table columns.
+func EndpointSliceEndpointColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&endpointSliceEndpoint{})
+}
+
+func createEndpointSliceEndpoint(es discoveryv1.EndpointSlice, e discoveryv1.Endpoint, address string) *endpointSliceEndpoint {
+	item := &endpointSliceEndpoint{
+		CommonNamespacedFields: k8s.GetCommonNamespacedFields(es.ObjectMeta),
+		ServiceName:            es.Labels[discoveryv1.LabelServiceName],
+		AddressType:            es.AddressType,
+		Address:                address,
+		Hostname:               e.Hostname,
+		Ready:                  e.Conditions.Ready,
+		Serving:                e.Conditions.Serving,
+		Terminating:            e.Conditions.Terminating,
+	}
+	// Unknown ready state should be interpreted as ready.
+	if item.Ready == nil {
+		ready := true
+		item.Ready = &ready
+	}
+	if e.NodeName != nil {
+		item.NodeName = *e.NodeName
+	}
+	if e.Zone != nil {
+		item.Zone = *e.Zone
+	}
+	if e.TargetRef != nil {
+		item.TargetRefKind = e.TargetRef.Kind
+		item.TargetRefNamespace = e.TargetRef.Namespace
+		item.TargetRefName = e.TargetRef.Name
+		item.TargetRefUID = e.TargetRef.UID
+	}
+	return item
+}
+
+// EndpointSliceEndpointsGenerate generates one row per kubernetes endpoint slice endpoint address as Osquery table data.
+func EndpointSliceEndpointsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	ess, err := listEndpointSlices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, es := range ess {
+		for _, e := range es.Endpoints {
+			for _, a := range e.Addresses {
+				item := createEndpointSliceEndpoint(es, e, a)
+				results = append(results, k8s.ToMap(item))
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,188 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	yes       = true
+	no        = false
+	node1     = "node1"
+	node2     = "node2"
+	zone      = "us-east-1a"
+	port      = int32(8080)
+	webMeta   = metav1.ObjectMeta{Name: "web-abcde", Namespace: "default", UID: types.UID("e123"), Labels: map[string]string{"kubernetes.io/service-name": "web"}}
+	webPodRef = &v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web-1", UID: types.UID("p123")}
+)
+
+func init() {
+	k8s.SetClient(fake.NewSimpleClientset(&discoveryv1.EndpointSlice{
+		ObjectMeta:  webMeta,
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports:       []discoveryv1.EndpointPort{{Port: &port}},
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &yes, Serving: &yes, Terminating: &no},
+				NodeName:   &node1,
+				Zone:       &zone,
+				TargetRef:  webPodRef,
+			},
+			{
+				Addresses:  []string{"10.0.0.2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &no},
+				NodeName:   &node2,
+			},
+			{
+				Addresses: []string{"10.0.0.3"},
+			},
+		},
+	}), types.UID("c123"))
+}
+
+// withV1beta1Client runs f with a client set that only serves discovery.k8s.io/v1beta1 endpoint slices.
+func withV1beta1Client(f func()) {
+	client := k8s.GetClient(context.TODO())
+	defer k8s.SetClient(client, types.UID("c123"))
+
+	fc := fake.NewSimpleClientset(&v1beta1.EndpointSlice{
+		ObjectMeta:  webMeta,
+		AddressType: v1beta1.AddressTypeIPv4,
+		Ports:       []v1beta1.EndpointPort{{Port: &port}},
+		Endpoints: []v1beta1.Endpoint{
+			{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: v1beta1.EndpointConditions{Ready: &yes, Serving: &yes, Terminating: &no},
+				NodeName:   &node1,
+				Topology:   map[string]string{"topology.kubernetes.io/zone": zone},
+				TargetRef:  webPodRef,
+			},
+			{
+				Addresses:  []string{"10.0.0.2"},
+				Conditions: v1beta1.EndpointConditions{Ready: &no},
+				Topology:   map[string]string{"kubernetes.io/hostname": node2},
+			},
+			{
+				Addresses: []string{"10.0.0.3"},
+			},
+		},
+	})
+	fc.PrependReactor("list", "endpointslices", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version == "v1" {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+	k8s.SetClient(fc, types.UID("c123"))
+	f()
+}
+
+var expectedEndpointSlices = []map[string]string{
+	{
+		"address_type":       "IPv4",
+		"cluster_uid":        "c123",
+		"creation_timestamp": "0",
+		"endpoint_count":     "3",
+		"labels":             "{\"kubernetes.io/service-name\":\"web\"}",
+		"name":               "web-abcde",
+		"namespace":          "default",
+		"ports":              "[{\"port\":8080}]",
+		"service_name":       "web",
+		"uid":                "e123",
+	},
+}
+
+var expectedEndpointSliceEndpoints = []map[string]string{
+	{
+		"address":              "10.0.0.1",
+		"address_type":         "IPv4",
+		"cluster_uid":          "c123",
+		"creation_timestamp":   "0",
+		"labels":               "{\"kubernetes.io/service-name\":\"web\"}",
+		"name":                 "web-abcde",
+		"namespace":            "default",
+		"node_name":            "node1",
+		"ready":                "1",
+		"service_name":         "web",
+		"serving":              "1",
+		"target_ref_kind":      "Pod",
+		"target_ref_name":      "web-1",
+		"target_ref_namespace": "default",
+		"target_ref_uid":       "p123",
+		"terminating":          "0",
+		"uid":                  "e123",
+		"zone":                 "us-east-1a",
+	},
+	{
+		"address":            "10.0.0.2",
+		"address_type":       "IPv4",
+		"cluster_uid":        "c123",
+		"creation_timestamp": "0",
+		"labels":             "{\"kubernetes.io/service-name\":\"web\"}",
+		"name":               "web-abcde",
+		"namespace":          "default",
+		"node_name":          "node2",
+		"ready":              "0",
+		"service_name":       "web",
+		"uid":                "e123",
+	},
+	{
+		"address":            "10.0.0.3",
+		"address_type":       "IPv4",
+		"cluster_uid":        "c123",
+		"creation_timestamp": "0",
+		"labels":             "{\"kubernetes.io/service-name\":\"web\"}",
+		"name":               "web-abcde",
+		"namespace":          "default",
+		"ready":              "1",
+		"service_name":       "web",
+		"uid":                "e123",
+	},
+}
+
+func TestEndpointSlicesGenerate(t *testing.T) {
+	ess, err := EndpointSlicesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, expectedEndpointSlices, ess)
+
+	withV1beta1Client(func() {
+		ess, err := EndpointSlicesGenerate(context.TODO(), table.QueryContext{})
+		assert.Nil(t, err)
+		assert.Equal(t, expectedEndpointSlices, ess)
+	})
+}
+
+func TestEndpointSliceEndpointsGenerate(t *testing.T) {
+	eps, err := EndpointSliceEndpointsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, expectedEndpointSliceEndpoints, eps)
+
+	withV1beta1Client(func() {
+		eps, err := EndpointSliceEndpointsGenerate(context.TODO(), table.QueryContext{})
+		assert.Nil(t, err)
+		assert.Equal(t, expectedEndpointSliceEndpoints, eps)
+	})
+}
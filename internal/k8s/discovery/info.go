@@ -19,7 +19,9 @@ import (
 )
 
 type info struct {
-	ClusterUID types.UID
+	ClusterUID       types.UID
+	ClusterName      string
+	ClusterUIDSource string
 	version.Info
 }
 
@@ -32,14 +34,16 @@ func InfoColumns() []table.ColumnDefinition {
 func InfoGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	results := make([]map[string]string, 0)
 
-	sv, err := k8s.GetClient().Discovery().ServerVersion()
+	sv, err := k8s.GetClient(ctx).Discovery().ServerVersion()
 	if err != nil {
 		return nil, err
 	}
 
 	item := &info{
-		ClusterUID: k8s.GetClusterUID(),
-		Info:       *sv,
+		ClusterUID:       k8s.GetClusterUID(),
+		ClusterName:      k8s.GetClusterName(),
+		ClusterUIDSource: k8s.GetClusterUIDSource(),
+		Info:             *sv,
 	}
 	results = append(results, k8s.ToMap(item))
 
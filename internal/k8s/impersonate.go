@@ -0,0 +1,195 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kolide/osquery-go/gen/osquery"
+	"github.com/kolide/osquery-go/plugin/table"
+	"k8s.io/client-go/rest"
+)
+
+// ImpersonateUserColumn is the column added to every table. When it is constrained in a query,
+// all API server calls made to generate the table are impersonating the given user.
+const ImpersonateUserColumn = "impersonate_user"
+
+// hiddenColumnOption is the osquery HIDDEN column option. Hidden columns can be constrained and selected
+// by name, but are not returned by SELECT *.
+const hiddenColumnOption = "16"
+
+// serviceAccountUserPrefix is the prefix of service account user names: system:serviceaccount:<namespace>:<name>.
+const serviceAccountUserPrefix = "system:serviceaccount:"
+
+// errImpersonateDisabled is returned when impersonate_user column is constrained without allowed users configured.
+var errImpersonateDisabled = errors.New("impersonate_user is disabled, allow users to impersonate with --impersonate-allow")
+
+type clientKey struct{}
+
+// Cached client sets are evicted once they are unused for impersonatedClientsTTL, or when the cache is full
+// starting from the least recently used one.
+var (
+	maxImpersonatedClients = 64
+	impersonatedClientsTTL = 10 * time.Minute
+)
+
+// impersonatedClientSets is a cached client set impersonating a user.
+type impersonatedClientSets struct {
+	clients  *clientSets
+	lastUsed time.Time
+}
+
+// Client sets impersonating the users requested in queries. Protected by the client lock.
+var impersonatedClients = make(map[string]*impersonatedClientSets)
+
+// evictImpersonatedClients removes the expired client sets and the least recently used ones until there is
+// room for another client set. Must be called with the client lock held.
+func evictImpersonatedClients(now time.Time) {
+	for user, cs := range impersonatedClients {
+		if now.Sub(cs.lastUsed) > impersonatedClientsTTL {
+			delete(impersonatedClients, user)
+		}
+	}
+
+	for len(impersonatedClients) >= maxImpersonatedClients {
+		oldest := ""
+		for user, cs := range impersonatedClients {
+			if oldest == "" || cs.lastUsed.Before(impersonatedClients[oldest].lastUsed) {
+				oldest = user
+			}
+		}
+		delete(impersonatedClients, oldest)
+	}
+}
+
+// isImpersonateAllowed returns nil if the user matches one of the allowed users.
+func isImpersonateAllowed(user string, allowed []string) error {
+	if len(allowed) == 0 {
+		return errImpersonateDisabled
+	}
+	for _, a := range allowed {
+		if a == user || (strings.HasSuffix(a, "*") && strings.HasPrefix(user, strings.TrimSuffix(a, "*"))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("impersonating %s is not allowed", user)
+}
+
+// getImpersonatedGroups returns the groups to impersonate along with the user. API server adds service account
+// groups only when no groups are impersonated, so they are added explicitly for service account users.
+func getImpersonatedGroups(user string, groups []string) []string {
+	results := append([]string{}, groups...)
+	if strings.HasPrefix(user, serviceAccountUserPrefix) {
+		if parts := strings.Split(strings.TrimPrefix(user, serviceAccountUserPrefix), ":"); len(parts) == 2 {
+			results = append(results, "system:serviceaccounts", "system:serviceaccounts:"+parts[0])
+		}
+	}
+	return results
+}
+
+func getImpersonatedClients(user string) (*clientSets, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	now := time.Now()
+	if cs, ok := impersonatedClients[user]; ok && now.Sub(cs.lastUsed) <= impersonatedClientsTTL {
+		cs.lastUsed = now
+		return cs.clients, nil
+	}
+	if restConfig == nil {
+		return nil, errors.New("impersonation requires API server configuration")
+	}
+
+	if err := isImpersonateAllowed(user, options.ImpersonateAllowedUsers); err != nil {
+		return nil, err
+	}
+
+	config := rest.CopyConfig(restConfig)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   getImpersonatedGroups(user, options.ImpersonateGroups),
+	}
+	cs, err := newClientSets(config)
+	if err != nil {
+		return nil, err
+	}
+	delete(impersonatedClients, user)
+	evictImpersonatedClients(now)
+	impersonatedClients[user] = &impersonatedClientSets{clients: cs, lastUsed: now}
+	return cs, nil
+}
+
+// ImpersonateColumns appends impersonate_user column to the provided table columns.
+func ImpersonateColumns(columns []table.ColumnDefinition) []table.ColumnDefinition {
+	return append(columns, table.TextColumn(ImpersonateUserColumn))
+}
+
+// ImpersonatePlugin is a table plugin that reports impersonate_user column as hidden.
+type ImpersonatePlugin struct {
+	*table.Plugin
+}
+
+// HideImpersonateColumn returns the table plugin with impersonate_user column hidden from SELECT *.
+func HideImpersonateColumn(plugin *table.Plugin) *ImpersonatePlugin {
+	return &ImpersonatePlugin{Plugin: plugin}
+}
+
+// Routes returns the table columns with hidden option set on impersonate_user column.
+func (p *ImpersonatePlugin) Routes() osquery.ExtensionPluginResponse {
+	routes := p.Plugin.Routes()
+	for _, r := range routes {
+		if r["id"] == "column" && r["name"] == ImpersonateUserColumn {
+			r["op"] = hiddenColumnOption
+		}
+	}
+	return routes
+}
+
+// Call handles columns action using the routes with hidden column. Other actions are handled by the table plugin.
+func (p *ImpersonatePlugin) Call(ctx context.Context, request osquery.ExtensionPluginRequest) osquery.ExtensionResponse {
+	if request["action"] == "columns" {
+		return osquery.ExtensionResponse{
+			Status:   &osquery.ExtensionStatus{Code: 0, Message: "OK"},
+			Response: p.Routes(),
+		}
+	}
+	return p.Plugin.Call(ctx, request)
+}
+
+// ImpersonateGenerate wraps a table generate function to run with a client set impersonating the user
+// from impersonate_user column constraint. Only the users allowed by Options.ImpersonateAllowedUsers can be
+// impersonated, along with the groups from Options.ImpersonateGroups. The user is reported back in every row,
+// otherwise osquery would filter out the rows not matching the constraint.
+func ImpersonateGenerate(gen table.GenerateFunc) table.GenerateFunc {
+	return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		user, ok := GetConstraint(queryContext, ImpersonateUserColumn)
+		if !ok {
+			return gen(ctx, queryContext)
+		}
+
+		cs, err := getImpersonatedClients(user)
+		if err != nil {
+			return nil, err
+		}
+
+		results, err := gen(context.WithValue(ctx, clientKey{}, cs), queryContext)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range results {
+			row[ImpersonateUserColumn] = user
+		}
+		return results, nil
+	}
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kolide/osquery-go/gen/osquery"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestImpersonateColumns(t *testing.T) {
+	assert.Equal(t, []table.ColumnDefinition{
+		table.TextColumn("name"),
+		table.TextColumn("impersonate_user"),
+	}, ImpersonateColumns([]table.ColumnDefinition{table.TextColumn("name")}))
+}
+
+func TestHideImpersonateColumn(t *testing.T) {
+	p := HideImpersonateColumn(table.NewPlugin("t1", ImpersonateColumns([]table.ColumnDefinition{table.TextColumn("name")}), nil))
+	routes := osquery.ExtensionPluginResponse{
+		{"id": "column", "name": "name", "type": "TEXT", "op": "0"},
+		{"id": "column", "name": "impersonate_user", "type": "TEXT", "op": "16"},
+	}
+	assert.Equal(t, routes, p.Routes())
+
+	resp := p.Call(context.TODO(), osquery.ExtensionPluginRequest{"action": "columns"})
+	assert.Equal(t, int32(0), resp.Status.Code)
+	assert.Equal(t, routes, resp.Response)
+
+	resp = p.Call(context.TODO(), osquery.ExtensionPluginRequest{"action": "unknown"})
+	assert.Equal(t, int32(1), resp.Status.Code)
+}
+
+func TestIsImpersonateAllowed(t *testing.T) {
+	allowed := []string{"u1", "system:serviceaccount:team-a:*"}
+	assert.Nil(t, isImpersonateAllowed("u1", allowed))
+	assert.Nil(t, isImpersonateAllowed("system:serviceaccount:team-a:sa1", allowed))
+	assert.Error(t, isImpersonateAllowed("u2", allowed))
+	assert.Error(t, isImpersonateAllowed("system:admin", allowed))
+	assert.Error(t, isImpersonateAllowed("system:serviceaccount:team-b:sa1", allowed))
+	assert.Equal(t, errImpersonateDisabled, isImpersonateAllowed("u1", nil))
+}
+
+func TestGetImpersonatedGroups(t *testing.T) {
+	assert.Equal(t, []string{}, getImpersonatedGroups("u1", nil))
+	assert.Equal(t, []string{"g1"}, getImpersonatedGroups("u1", []string{"g1"}))
+	assert.Equal(t, []string{"g1", "system:serviceaccounts", "system:serviceaccounts:default"},
+		getImpersonatedGroups("system:serviceaccount:default:sa1", []string{"g1"}))
+	assert.Equal(t, []string{}, getImpersonatedGroups("system:serviceaccount:invalid", nil))
+}
+
+func TestImpersonateGenerate(t *testing.T) {
+	defer func() {
+		restConfig = nil
+		options = Options{}
+		SetClient(fake.NewSimpleClientset(), types.UID(""))
+	}()
+
+	client := fake.NewSimpleClientset()
+	SetClient(client, types.UID(""))
+
+	var used kubernetes.Interface
+	gen := ImpersonateGenerate(func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		used = GetClient(ctx)
+		return []map[string]string{{"name": "n1"}}, nil
+	})
+
+	rows, err := gen(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "n1"}}, rows)
+	assert.Equal(t, client, used)
+
+	qc := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"impersonate_user": {
+				Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "system:serviceaccount:default:sa1"}},
+			},
+		},
+	}
+	_, err = gen(context.TODO(), qc)
+	assert.Error(t, err, "Impersonation should fail without API server configuration")
+
+	restConfig = &rest.Config{Host: "https://127.0.0.1:6443"}
+	_, err = gen(context.TODO(), qc)
+	assert.Equal(t, errImpersonateDisabled, err, "Impersonation should fail without allowed users")
+
+	options.ImpersonateAllowedUsers = []string{"system:serviceaccount:team-a:*"}
+	_, err = gen(context.TODO(), qc)
+	assert.Error(t, err, "Impersonation should fail for users that are not allowed")
+
+	options.ImpersonateAllowedUsers = []string{"system:serviceaccount:default:*"}
+	rows, err = gen(context.TODO(), qc)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "n1", "impersonate_user": "system:serviceaccount:default:sa1"}}, rows)
+	assert.NotEqual(t, client, used)
+	assert.Equal(t, used, impersonatedClients["system:serviceaccount:default:sa1"].clients.kubernetes)
+}
+
+func TestImpersonatedClientsEviction(t *testing.T) {
+	max := maxImpersonatedClients
+	defer func() {
+		maxImpersonatedClients = max
+		restConfig = nil
+		options = Options{}
+		impersonatedClients = make(map[string]*impersonatedClientSets)
+	}()
+
+	maxImpersonatedClients = 2
+	restConfig = &rest.Config{Host: "https://127.0.0.1:6443"}
+	options.ImpersonateAllowedUsers = []string{"u*"}
+	impersonatedClients = make(map[string]*impersonatedClientSets)
+
+	u1, err := getImpersonatedClients("u1")
+	assert.Nil(t, err)
+	_, err = getImpersonatedClients("u2")
+	assert.Nil(t, err)
+
+	// u1 is more recently used than u2 and reused from the cache
+	impersonatedClients["u2"].lastUsed = impersonatedClients["u2"].lastUsed.Add(-time.Minute)
+	cs, err := getImpersonatedClients("u1")
+	assert.Nil(t, err)
+	assert.Same(t, u1, cs)
+
+	_, err = getImpersonatedClients("u3")
+	assert.Nil(t, err)
+	assert.Len(t, impersonatedClients, 2)
+	assert.Contains(t, impersonatedClients, "u1")
+	assert.Contains(t, impersonatedClients, "u3")
+
+	// Expired client sets are recreated
+	impersonatedClients["u1"].lastUsed = time.Now().Add(-impersonatedClientsTTL - time.Minute)
+	cs, err = getImpersonatedClients("u1")
+	assert.Nil(t, err)
+	assert.NotSame(t, u1, cs)
+	assert.Len(t, impersonatedClients, 2)
+
+	impersonatedClients["u3"].lastUsed = time.Now().Add(-impersonatedClientsTTL - time.Minute)
+	_, err = getImpersonatedClients("u4")
+	assert.Nil(t, err)
+	assert.Len(t, impersonatedClients, 2)
+	assert.NotContains(t, impersonatedClients, "u3")
+}
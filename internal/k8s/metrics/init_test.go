@@ -0,0 +1,97 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+var (
+	// Fake metrics client set uses the same resource names as the metrics API instead of guessing them from kinds
+	podMetricsResource  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+	nodeMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+
+	timestamp = metav1.NewTime(time.Unix(1600000000, 0))
+	window    = metav1.Duration{Duration: 30 * time.Second}
+)
+
+func usage(cpu, memory string) v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpu),
+		v1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func controller(kind, name string, uid types.UID) []metav1.OwnerReference {
+	t := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, UID: uid, Controller: &t}}
+}
+
+func init() {
+	spec := v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name: "web",
+				Resources: v1.ResourceRequirements{
+					Requests: usage("500m", "256Mi"),
+					Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				},
+			},
+			{Name: "sidecar"},
+		},
+	}
+	k8s.SetClient(fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("d123")},
+			Spec:       appsv1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: spec}},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "default", UID: types.UID("r123"), OwnerReferences: controller("Deployment", "web", "d123")},
+			Spec:       appsv1.ReplicaSetSpec{Template: v1.PodTemplateSpec{Spec: spec}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: types.UID("p123"), OwnerReferences: controller("ReplicaSet", "web-abc", "r123")},
+			Spec:       spec,
+		},
+	), types.UID("c123"))
+
+	mc := metricsfake.NewSimpleClientset()
+	if err := mc.Tracker().Create(podMetricsResource, &v1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+		Timestamp:  timestamp,
+		Window:     window,
+		Containers: []v1beta1.ContainerMetrics{
+			{Name: "web", Usage: usage("250m", "128Mi")},
+			{Name: "sidecar", Usage: usage("1", "1Gi")},
+		},
+	}, "default"); err != nil {
+		panic(err)
+	}
+	if err := mc.Tracker().Create(nodeMetricsResource, &v1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Timestamp:  timestamp,
+		Window:     window,
+		Usage:      usage("1500m", "4Gi"),
+	}, ""); err != nil {
+		panic(err)
+	}
+	k8s.SetMetricsClient(mc)
+}
@@ -0,0 +1,26 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"log"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// isUnavailable returns true if metrics.k8s.io API is not registered or the metrics server behind it is not
+// serving requests. Tables return no rows instead of failing in that case, since metrics server is optional.
+func isUnavailable(err error) bool {
+	if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+		log.Printf("Metrics API is not available: %s", err)
+		return true
+	}
+	return false
+}
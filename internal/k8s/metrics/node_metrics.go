@@ -0,0 +1,69 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type nodeMetrics struct {
+	ClusterUID    types.UID
+	NodeName      string
+	CPUMillicores int64
+	MemoryBytes   int64
+	Window        string
+	Timestamp     metav1.Time
+}
+
+// NodeMetricsColumns returns kubernetes node resource usage fields as Osquery table columns.
+func NodeMetricsColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&nodeMetrics{})
+}
+
+// NodeMetricsGenerate generates the resource usage of kubernetes nodes as Osquery table data.
+// No rows are returned if metrics API is not available.
+func NodeMetricsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+	results := make([]map[string]string, 0)
+
+	for {
+		nms, err := k8s.GetMetricsClient(ctx).MetricsV1beta1().NodeMetricses().List(context.TODO(), options)
+		if err != nil {
+			if isUnavailable(err) {
+				return results, nil
+			}
+			return nil, err
+		}
+
+		for _, nm := range nms.Items {
+			item := &nodeMetrics{
+				ClusterUID:    k8s.GetClusterUID(),
+				NodeName:      nm.Name,
+				CPUMillicores: nm.Usage.Cpu().MilliValue(),
+				MemoryBytes:   nm.Usage.Memory().Value(),
+				Window:        nm.Window.Duration.String(),
+				Timestamp:     nm.Timestamp,
+			}
+			results = append(results, k8s.ToMap(item))
+		}
+
+		if nms.Continue == "" {
+			break
+		}
+		options.Continue = nms.Continue
+	}
+
+	return results, nil
+}
@@ -0,0 +1,42 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestNodeMetricsGenerate(t *testing.T) {
+	nms, err := NodeMetricsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"cluster_uid":    "c123",
+			"node_name":      "node1",
+			"cpu_millicores": "1500",
+			"memory_bytes":   "4294967296",
+			"window":         "30s",
+			"timestamp":      "1600000000",
+		},
+	}, nms)
+}
+
+func TestNodeMetricsGenerateUnavailable(t *testing.T) {
+	withMetricsError(apierrors.NewNotFound(nodeMetricsResource.GroupResource(), ""), func() {
+		nms, err := NodeMetricsGenerate(context.TODO(), table.QueryContext{})
+		assert.Nil(t, err)
+		assert.Empty(t, nms)
+	})
+}
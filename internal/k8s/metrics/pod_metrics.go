@@ -0,0 +1,88 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+type podMetrics struct {
+	ClusterUID    types.UID
+	Namespace     string
+	PodName       string
+	ContainerName string
+	CPUMillicores int64
+	MemoryBytes   int64
+	Window        string
+	Timestamp     metav1.Time
+}
+
+// PodMetricsColumns returns kubernetes pod container resource usage fields as Osquery table columns.
+func PodMetricsColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&podMetrics{})
+}
+
+// ListPodMetrics returns the current resource usage of all pods. No pod metrics are returned if metrics API is not available.
+func ListPodMetrics(ctx context.Context) ([]v1beta1.PodMetrics, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1beta1.PodMetrics, 0)
+
+	for {
+		pms, err := k8s.GetMetricsClient(ctx).MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			if isUnavailable(err) {
+				return results, nil
+			}
+			return nil, err
+		}
+
+		results = append(results, pms.Items...)
+
+		if pms.Continue == "" {
+			break
+		}
+		options.Continue = pms.Continue
+	}
+
+	return results, nil
+}
+
+// PodMetricsGenerate generates the resource usage of kubernetes pod containers as Osquery table data.
+func PodMetricsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	pms, err := ListPodMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, pm := range pms {
+		for _, c := range pm.Containers {
+			item := &podMetrics{
+				ClusterUID:    k8s.GetClusterUID(),
+				Namespace:     pm.Namespace,
+				PodName:       pm.Name,
+				ContainerName: c.Name,
+				CPUMillicores: c.Usage.Cpu().MilliValue(),
+				MemoryBytes:   c.Usage.Memory().Value(),
+				Window:        pm.Window.Duration.String(),
+				Timestamp:     pm.Timestamp,
+			}
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results, nil
+}
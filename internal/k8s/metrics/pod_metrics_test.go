@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// withMetricsError runs f with a metrics client that fails all requests with err.
+func withMetricsError(err error, f func()) {
+	mc := k8s.GetMetricsClient(context.TODO())
+	defer k8s.SetMetricsClient(mc)
+
+	fmc := metricsfake.NewSimpleClientset()
+	fmc.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, err
+	})
+	k8s.SetMetricsClient(fmc)
+	f()
+}
+
+func TestPodMetricsGenerate(t *testing.T) {
+	pms, err := PodMetricsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"cluster_uid":    "c123",
+			"namespace":      "default",
+			"pod_name":       "web-1",
+			"container_name": "web",
+			"cpu_millicores": "250",
+			"memory_bytes":   "134217728",
+			"window":         "30s",
+			"timestamp":      "1600000000",
+		},
+		{
+			"cluster_uid":    "c123",
+			"namespace":      "default",
+			"pod_name":       "web-1",
+			"container_name": "sidecar",
+			"cpu_millicores": "1000",
+			"memory_bytes":   "1073741824",
+			"window":         "30s",
+			"timestamp":      "1600000000",
+		},
+	}, pms)
+}
+
+func TestPodMetricsGenerateUnavailable(t *testing.T) {
+	notFound := apierrors.NewNotFound(podMetricsResource.GroupResource(), "")
+	unavailable := apierrors.NewServiceUnavailable("metrics server is not ready")
+
+	for _, e := range []error{notFound, unavailable} {
+		withMetricsError(e, func() {
+			pms, err := PodMetricsGenerate(context.TODO(), table.QueryContext{})
+			assert.Nil(t, err)
+			assert.Empty(t, pms)
+		})
+	}
+
+	withMetricsError(errors.New("connection refused"), func() {
+		_, err := PodMetricsGenerate(context.TODO(), table.QueryContext{})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,220 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// recommendationMargin is the headroom added on top of the observed usage.
+	recommendationMargin = 0.15
+
+	// Recommendations are never lower than these.
+	minCPUMillicores = 10
+	minMemoryBytes   = 16 * mebibyte
+
+	mebibyte = 1024 * 1024
+)
+
+type resourceRecommendation struct {
+	ClusterUID      types.UID
+	Namespace       string
+	WorkloadKind    string
+	WorkloadName    string
+	WorkloadUID     types.UID
+	ContainerName   string
+	Samples         int
+	FirstSampleTime metav1.Time
+	LastSampleTime  metav1.Time
+
+	CPUUsageP50Millicores           int64
+	CPUUsageP95Millicores           int64
+	CPUUsageMaxMillicores           int64
+	CPURequestMillicores            *int64
+	CPULimitMillicores              *int64
+	CPURecommendedRequestMillicores int64
+	CPUSavingsMillicores            *int64
+
+	MemoryUsageP50Bytes           int64
+	MemoryUsageP95Bytes           int64
+	MemoryUsageMaxBytes           int64
+	MemoryRequestBytes            *int64
+	MemoryLimitBytes              *int64
+	MemoryRecommendedRequestBytes int64
+	MemorySavingsBytes            *int64
+}
+
+// ResourceRecommendationColumns returns kubernetes workload container right-sizing fields as Osquery table columns.
+func ResourceRecommendationColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&resourceRecommendation{})
+}
+
+// usageStats holds the sorted values of a resource usage to calculate percentiles.
+type usageStats []int64
+
+func newUsageStats(values []int64) usageStats {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return usageStats(values)
+}
+
+// percentile returns the nearest rank percentile.
+func (s usageStats) percentile(p float64) int64 {
+	if len(s) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(s)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return s[i]
+}
+
+func (s usageStats) max() int64 {
+	return s.percentile(100)
+}
+
+// recommendCPU returns p95 CPU usage with margin. CPU is compressible, so occasional usage above request is throttled.
+func recommendCPU(s usageStats) int64 {
+	r := int64(math.Ceil(float64(s.percentile(95)) * (1 + recommendationMargin)))
+	if r < minCPUMillicores {
+		return minCPUMillicores
+	}
+	return r
+}
+
+// recommendMemory returns peak memory usage with margin rounded up to MiB. Memory is not compressible,
+// so containers exceeding the request are the first to be evicted under node memory pressure.
+func recommendMemory(s usageStats) int64 {
+	r := int64(math.Ceil(float64(s.max())*(1+recommendationMargin)/mebibyte)) * mebibyte
+	if r < minMemoryBytes {
+		return minMemoryBytes
+	}
+	return r
+}
+
+// savings returns the difference between the current request and the recommendation.
+// Negative savings mean the container is under provisioned.
+func savings(request *int64, recommended int64) *int64 {
+	if request == nil {
+		return nil
+	}
+	s := *request - recommended
+	return &s
+}
+
+// getContainerResources returns the CPU and memory requests and limits of the container in the pod spec.
+// Resources that are not set are nil.
+func getContainerResources(spec v1.PodSpec, name string) (cpuRequest, cpuLimit, memoryRequest, memoryLimit *int64) {
+	for _, c := range spec.Containers {
+		if c.Name != name {
+			continue
+		}
+		if q, ok := c.Resources.Requests[v1.ResourceCPU]; ok {
+			v := q.MilliValue()
+			cpuRequest = &v
+		}
+		if q, ok := c.Resources.Limits[v1.ResourceCPU]; ok {
+			v := q.MilliValue()
+			cpuLimit = &v
+		}
+		if q, ok := c.Resources.Requests[v1.ResourceMemory]; ok {
+			v := q.Value()
+			memoryRequest = &v
+		}
+		if q, ok := c.Resources.Limits[v1.ResourceMemory]; ok {
+			v := q.Value()
+			memoryLimit = &v
+		}
+	}
+	return
+}
+
+func getResourceRecommendation(w workload.Workload, container string, samples []sample) *resourceRecommendation {
+	cpu := make([]int64, 0, len(samples))
+	memory := make([]int64, 0, len(samples))
+	item := &resourceRecommendation{
+		ClusterUID:    k8s.GetClusterUID(),
+		Namespace:     w.ObjectMeta.Namespace,
+		WorkloadKind:  w.Kind,
+		WorkloadName:  w.ObjectMeta.Name,
+		WorkloadUID:   w.ObjectMeta.UID,
+		ContainerName: container,
+		Samples:       len(samples),
+	}
+	for _, s := range samples {
+		cpu = append(cpu, s.cpuMillicores)
+		memory = append(memory, s.memoryBytes)
+		if item.FirstSampleTime.IsZero() || s.timestamp.Before(item.FirstSampleTime.Time) {
+			item.FirstSampleTime = metav1.NewTime(s.timestamp)
+		}
+		if s.timestamp.After(item.LastSampleTime.Time) {
+			item.LastSampleTime = metav1.NewTime(s.timestamp)
+		}
+	}
+
+	cpuStats := newUsageStats(cpu)
+	item.CPUUsageP50Millicores = cpuStats.percentile(50)
+	item.CPUUsageP95Millicores = cpuStats.percentile(95)
+	item.CPUUsageMaxMillicores = cpuStats.max()
+	item.CPURecommendedRequestMillicores = recommendCPU(cpuStats)
+
+	memoryStats := newUsageStats(memory)
+	item.MemoryUsageP50Bytes = memoryStats.percentile(50)
+	item.MemoryUsageP95Bytes = memoryStats.percentile(95)
+	item.MemoryUsageMaxBytes = memoryStats.max()
+	item.MemoryRecommendedRequestBytes = recommendMemory(memoryStats)
+
+	item.CPURequestMillicores, item.CPULimitMillicores, item.MemoryRequestBytes, item.MemoryLimitBytes = getContainerResources(w.Spec, container)
+	item.CPUSavingsMillicores = savings(item.CPURequestMillicores, item.CPURecommendedRequestMillicores)
+	item.MemorySavingsBytes = savings(item.MemoryRequestBytes, item.MemoryRecommendedRequestBytes)
+
+	return item
+}
+
+// ResourceRecommendationsGenerate generates the observed resource usage and recommended requests of kubernetes
+// workload containers as Osquery table data. Usage is aggregated over all pods of the workload in the retention
+// period. Savings are per pod. Only workloads that currently exist and can be listed are included.
+func ResourceRecommendationsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	ts := getUsageSeries()
+	if ts == nil {
+		return nil, errSamplingDisabled
+	}
+
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[types.UID]workload.Workload)
+	for _, w := range ws {
+		byUID[w.ObjectMeta.UID] = w
+	}
+
+	results := make([]map[string]string, 0)
+	for wc, samples := range ts.byWorkload(time.Now()) {
+		w, ok := byUID[wc.uid]
+		if !ok {
+			continue
+		}
+		results = append(results, k8s.ToMap(getResourceRecommendation(w, wc.container, samples)))
+	}
+
+	return results, nil
+}
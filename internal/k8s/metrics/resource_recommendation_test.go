@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageStats(t *testing.T) {
+	s := newUsageStats([]int64{400, 100, 300, 200})
+	assert.Equal(t, int64(200), s.percentile(50))
+	assert.Equal(t, int64(400), s.percentile(95))
+	assert.Equal(t, int64(400), s.max())
+	assert.Equal(t, int64(100), s.percentile(0))
+	assert.Equal(t, int64(0), newUsageStats(nil).percentile(50))
+
+	assert.Equal(t, int64(460), recommendCPU(s))
+	assert.Equal(t, int64(minCPUMillicores), recommendCPU(newUsageStats([]int64{1})))
+	assert.Equal(t, int64(230*mebibyte), recommendMemory(newUsageStats([]int64{100 * mebibyte, 200 * mebibyte})))
+	assert.Equal(t, int64(minMemoryBytes), recommendMemory(newUsageStats([]int64{mebibyte})))
+}
+
+func TestResourceRecommendationsGenerate(t *testing.T) {
+	defer func() { usageSeries = nil }()
+
+	_, err := ResourceRecommendationsGenerate(context.TODO(), table.QueryContext{})
+	assert.Equal(t, errSamplingDisabled, err)
+
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	usageSeries = newTimeSeries(time.Minute, time.Hour)
+	web := workloadContainer{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "web"}
+	for i, v := range []sample{
+		{cpuMillicores: 100, memoryBytes: 100 * mebibyte},
+		{cpuMillicores: 300, memoryBytes: 120 * mebibyte},
+		{cpuMillicores: 400, memoryBytes: 200 * mebibyte},
+		{cpuMillicores: 200, memoryBytes: 110 * mebibyte},
+	} {
+		v.timestamp = start.Add(time.Duration(i) * time.Minute)
+		usageSeries.add(containerKey{namespace: "default", pod: "web-" + strconv.Itoa(i%2), container: "web"}, web, v)
+	}
+	sidecar := workloadContainer{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "sidecar"}
+	usageSeries.add(containerKey{namespace: "default", pod: "web-0", container: "sidecar"}, sidecar, sample{timestamp: start, cpuMillicores: 1, memoryBytes: mebibyte})
+	deleted := workloadContainer{kind: "Deployment", namespace: "default", name: "old", uid: "d456", container: "old"}
+	usageSeries.add(containerKey{namespace: "default", pod: "old-0", container: "old"}, deleted, sample{timestamp: start, cpuMillicores: 1, memoryBytes: mebibyte})
+
+	first := strconv.FormatInt(start.Unix(), 10)
+	rrs, err := ResourceRecommendationsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"cluster_uid":                        "c123",
+			"namespace":                          "default",
+			"workload_kind":                      "Deployment",
+			"workload_name":                      "web",
+			"workload_uid":                       "d123",
+			"container_name":                     "web",
+			"samples":                            "4",
+			"first_sample_time":                  first,
+			"last_sample_time":                   strconv.FormatInt(start.Add(3*time.Minute).Unix(), 10),
+			"cpu_usage_p_50_millicores":          "200",
+			"cpu_usage_p_95_millicores":          "400",
+			"cpu_usage_max_millicores":           "400",
+			"cpu_request_millicores":             "500",
+			"cpu_limit_millicores":               "1000",
+			"cpu_recommended_request_millicores": "460",
+			"cpu_savings_millicores":             "40",
+			"memory_usage_p_50_bytes":            "115343360",
+			"memory_usage_p_95_bytes":            "209715200",
+			"memory_usage_max_bytes":             "209715200",
+			"memory_request_bytes":               "268435456",
+			"memory_recommended_request_bytes":   "241172480",
+			"memory_savings_bytes":               "27262976",
+		},
+		{
+			"cluster_uid":                        "c123",
+			"namespace":                          "default",
+			"workload_kind":                      "Deployment",
+			"workload_name":                      "web",
+			"workload_uid":                       "d123",
+			"container_name":                     "sidecar",
+			"samples":                            "1",
+			"first_sample_time":                  first,
+			"last_sample_time":                   first,
+			"cpu_usage_p_50_millicores":          "1",
+			"cpu_usage_p_95_millicores":          "1",
+			"cpu_usage_max_millicores":           "1",
+			"cpu_recommended_request_millicores": "10",
+			"memory_usage_p_50_bytes":            "1048576",
+			"memory_usage_p_95_bytes":            "1048576",
+			"memory_usage_max_bytes":             "1048576",
+			"memory_recommended_request_bytes":   "16777216",
+		},
+	}, rrs)
+}
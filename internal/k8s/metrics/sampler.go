@@ -0,0 +1,254 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	errSamplingDisabled = errors.New("resource usage sampling is disabled")
+	errInvalidInterval  = errors.New("resource usage sample interval must be positive")
+	errInvalidRetention = errors.New("resource usage retention must not be shorter than the sample interval")
+)
+
+// Time series of pod container resource usage. Nil until sampling is started with StartSampler.
+var (
+	usageLock   sync.RWMutex
+	usageSeries *timeSeries
+)
+
+type sample struct {
+	timestamp     time.Time
+	cpuMillicores int64
+	memoryBytes   int64
+}
+
+// workloadContainer identifies a container of a top level workload, like a deployment or a bare pod.
+type workloadContainer struct {
+	kind      string
+	namespace string
+	name      string
+	uid       types.UID
+	container string
+}
+
+// containerKey identifies a container of a pod.
+type containerKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// series is a ring buffer with the samples of one pod container. The buffer grows with the samples up to
+// the capacity. Oldest sample is overwritten once the buffer is full.
+type series struct {
+	workload workloadContainer
+	samples  []sample
+	capacity int
+	next     int
+}
+
+func newSeries(capacity int) *series {
+	return &series{capacity: capacity}
+}
+
+func (s *series) last() (sample, bool) {
+	if len(s.samples) == 0 {
+		return sample{}, false
+	}
+	return s.samples[(s.next-1+len(s.samples))%len(s.samples)], true
+}
+
+func (s *series) add(v sample) {
+	if len(s.samples) < s.capacity {
+		s.samples = append(s.samples, v)
+	} else {
+		s.samples[s.next] = v
+	}
+	s.next = (s.next + 1) % s.capacity
+}
+
+// since returns the samples taken after the given time, oldest first.
+func (s *series) since(t time.Time) []sample {
+	results := make([]sample, 0, len(s.samples))
+	start := 0
+	if len(s.samples) == s.capacity {
+		start = s.next
+	}
+	for i := 0; i < len(s.samples); i++ {
+		v := s.samples[(start+i)%len(s.samples)]
+		if v.timestamp.After(t) {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+// timeSeries keeps the resource usage samples of pod containers taken in the retention period.
+// Memory use is bounded by the number of pod containers, each holding at most retention / interval samples.
+// Containers of short lived pods only hold the samples taken while they ran.
+type timeSeries struct {
+	lock      sync.Mutex
+	retention time.Duration
+	capacity  int
+	series    map[containerKey]*series
+}
+
+func newTimeSeries(interval, retention time.Duration) *timeSeries {
+	return &timeSeries{
+		retention: retention,
+		capacity:  int(retention / interval),
+		series:    make(map[containerKey]*series),
+	}
+}
+
+// add records the sample unless it is the same metrics server scrape as the last sample of the container.
+func (ts *timeSeries) add(key containerKey, w workloadContainer, v sample) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	s, ok := ts.series[key]
+	if !ok {
+		s = newSeries(ts.capacity)
+		ts.series[key] = s
+	}
+	s.workload = w
+	if last, ok := s.last(); ok && !v.timestamp.After(last.timestamp) {
+		return
+	}
+	s.add(v)
+}
+
+// expire removes the containers without samples in the retention period.
+func (ts *timeSeries) expire(now time.Time) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	for key, s := range ts.series {
+		if last, ok := s.last(); !ok || !last.timestamp.After(now.Add(-ts.retention)) {
+			delete(ts.series, key)
+		}
+	}
+}
+
+// byWorkload returns the samples in the retention period grouped by workload container.
+func (ts *timeSeries) byWorkload(now time.Time) map[workloadContainer][]sample {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	results := make(map[workloadContainer][]sample)
+	for _, s := range ts.series {
+		if samples := s.since(now.Add(-ts.retention)); len(samples) > 0 {
+			results[s.workload] = append(results[s.workload], samples...)
+		}
+	}
+	return results
+}
+
+// topLevelWorkload follows the controller owner references of the object up to the workload that is not managed by another one.
+func topLevelWorkload(w workload.Workload, byUID map[types.UID]workload.Workload) workload.Workload {
+	for {
+		owner := metav1.GetControllerOf(&w.ObjectMeta)
+		if owner == nil {
+			return w
+		}
+		parent, ok := byUID[owner.UID]
+		if !ok {
+			return w
+		}
+		w = parent
+	}
+}
+
+// sampleUsage records the current resource usage of all pod containers along with the workload they belong to.
+func sampleUsage(ctx context.Context, ts *timeSeries, now time.Time) error {
+	pms, err := ListPodMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	byUID := make(map[types.UID]workload.Workload)
+	pods := make(map[string]workload.Workload)
+	for _, w := range ws {
+		byUID[w.ObjectMeta.UID] = w
+		if w.Kind == workload.KindPod {
+			pods[w.ObjectMeta.Namespace+"/"+w.ObjectMeta.Name] = w
+		}
+	}
+
+	for _, pm := range pms {
+		pod, ok := pods[pm.Namespace+"/"+pm.Name]
+		if !ok {
+			continue
+		}
+		top := topLevelWorkload(pod, byUID)
+
+		for _, c := range pm.Containers {
+			key := containerKey{namespace: pm.Namespace, pod: pm.Name, container: c.Name}
+			w := workloadContainer{kind: top.Kind, namespace: top.ObjectMeta.Namespace, name: top.ObjectMeta.Name, uid: top.ObjectMeta.UID, container: c.Name}
+			ts.add(key, w, sample{
+				timestamp:     pm.Timestamp.Time,
+				cpuMillicores: c.Usage.Cpu().MilliValue(),
+				memoryBytes:   c.Usage.Memory().Value(),
+			})
+		}
+	}
+
+	ts.expire(now)
+	return nil
+}
+
+// StartSampler starts sampling the resource usage of pod containers from metrics API in the background
+// at the given interval. Samples older than retention are discarded.
+func StartSampler(interval, retention time.Duration) error {
+	if interval <= 0 {
+		return errInvalidInterval
+	}
+	if retention < interval {
+		return errInvalidRetention
+	}
+
+	ts := newTimeSeries(interval, retention)
+	usageLock.Lock()
+	usageSeries = ts
+	usageLock.Unlock()
+
+	go wait.Until(func() {
+		if !k8s.IsReady() {
+			return
+		}
+		if err := sampleUsage(context.Background(), ts, time.Now()); err != nil {
+			log.Printf("Failed to sample resource usage: %s", err)
+		}
+	}, interval, wait.NeverStop)
+	return nil
+}
+
+func getUsageSeries() *timeSeries {
+	usageLock.RLock()
+	defer usageLock.RUnlock()
+
+	return usageSeries
+}
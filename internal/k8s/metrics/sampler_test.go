@@ -0,0 +1,117 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeries(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	s := newSeries(3)
+	_, ok := s.last()
+	assert.False(t, ok)
+	assert.Empty(t, s.since(start))
+
+	s.add(sample{timestamp: start, cpuMillicores: 0})
+	assert.Len(t, s.samples, 1)
+	last, ok := s.last()
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), last.cpuMillicores)
+
+	for i := 1; i < 5; i++ {
+		s.add(sample{timestamp: start.Add(time.Duration(i) * time.Minute), cpuMillicores: int64(i)})
+	}
+	last, ok = s.last()
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), last.cpuMillicores)
+	assert.Len(t, s.samples, 3)
+
+	cpu := func(samples []sample) []int64 {
+		results := make([]int64, 0)
+		for _, v := range samples {
+			results = append(results, v.cpuMillicores)
+		}
+		return results
+	}
+	assert.Equal(t, []int64{2, 3, 4}, cpu(s.since(start)))
+	assert.Equal(t, []int64{4}, cpu(s.since(start.Add(3*time.Minute))))
+}
+
+func TestTimeSeries(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	ts := newTimeSeries(time.Minute, time.Hour)
+	assert.Equal(t, 60, ts.capacity)
+
+	w := workloadContainer{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "web"}
+	ts.add(containerKey{namespace: "default", pod: "web-1", container: "web"}, w, sample{timestamp: start, cpuMillicores: 1})
+	ts.add(containerKey{namespace: "default", pod: "web-1", container: "web"}, w, sample{timestamp: start, cpuMillicores: 2})
+	ts.add(containerKey{namespace: "default", pod: "web-2", container: "web"}, w, sample{timestamp: start.Add(time.Hour), cpuMillicores: 3})
+
+	samples := ts.byWorkload(start.Add(time.Minute))
+	assert.Equal(t, 1, len(samples))
+	assert.ElementsMatch(t, []sample{{timestamp: start, cpuMillicores: 1}, {timestamp: start.Add(time.Hour), cpuMillicores: 3}}, samples[w])
+
+	ts.expire(start.Add(time.Hour))
+	assert.Equal(t, 1, len(ts.series))
+	assert.Equal(t, []sample{{timestamp: start.Add(time.Hour), cpuMillicores: 3}}, ts.byWorkload(start.Add(time.Hour))[w])
+}
+
+func TestTimeSeriesReplacedPods(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	ts := newTimeSeries(time.Minute, time.Hour)
+
+	w := workloadContainer{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "web"}
+	old := containerKey{namespace: "default", pod: "web-1", container: "web"}
+	replaced := containerKey{namespace: "default", pod: "web-2", container: "web"}
+	for i := 0; i < 30; i++ {
+		ts.add(old, w, sample{timestamp: start.Add(time.Duration(i) * time.Minute), cpuMillicores: 1})
+	}
+	for i := 30; i < 90; i++ {
+		ts.add(replaced, w, sample{timestamp: start.Add(time.Duration(i) * time.Minute), cpuMillicores: 2})
+		ts.expire(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	assert.Len(t, ts.series, 1)
+	assert.Len(t, ts.series[replaced].samples, 60)
+	assert.NotContains(t, ts.series, old)
+
+	samples := ts.byWorkload(start.Add(89 * time.Minute))[w]
+	assert.Len(t, samples, 60)
+	for _, v := range samples {
+		assert.Equal(t, int64(2), v.cpuMillicores)
+	}
+}
+
+func TestSampleUsage(t *testing.T) {
+	ts := newTimeSeries(time.Minute, time.Hour)
+	for i := 0; i < 2; i++ {
+		err := sampleUsage(context.TODO(), ts, timestamp.Add(time.Minute))
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, map[workloadContainer][]sample{
+		{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "web"}: {
+			{timestamp: timestamp.Time, cpuMillicores: 250, memoryBytes: 134217728},
+		},
+		{kind: "Deployment", namespace: "default", name: "web", uid: "d123", container: "sidecar"}: {
+			{timestamp: timestamp.Time, cpuMillicores: 1000, memoryBytes: 1073741824},
+		},
+	}, ts.byWorkload(timestamp.Add(time.Minute)))
+}
+
+func TestStartSampler(t *testing.T) {
+	assert.Equal(t, errInvalidInterval, StartSampler(0, time.Hour))
+	assert.Equal(t, errInvalidRetention, StartSampler(time.Hour, time.Minute))
+}
@@ -11,13 +11,18 @@ package networking
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Uptycs/kubequery/internal/k8s"
 	"github.com/kolide/osquery-go/plugin/table"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
 type ingress struct {
 	k8s.CommonNamespacedFields
 	v1.IngressSpec
@@ -29,13 +34,35 @@ func IngressColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&ingress{})
 }
 
+// ListIngresses returns all kubernetes ingresses in all namespaces.
+func ListIngresses(ctx context.Context) ([]v1.Ingress, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Ingress, 0)
+
+	for {
+		ingresses, err := k8s.GetClient(ctx).NetworkingV1().Ingresses(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, ingresses.Items...)
+
+		if ingresses.Continue == "" {
+			break
+		}
+		options.Continue = ingresses.Continue
+	}
+
+	return results, nil
+}
+
 // IngressesGenerate generates the kubernetes ingresses as Osquery table data.
 func IngressesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	options := metav1.ListOptions{}
 	results := make([]map[string]string, 0)
 
 	for {
-		ingresses, err := k8s.GetClient().NetworkingV1().Ingresses(metav1.NamespaceAll).List(context.TODO(), options)
+		ingresses, err := k8s.GetClient(ctx).NetworkingV1().Ingresses(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -57,3 +84,179 @@ func IngressesGenerate(ctx context.Context, queryContext table.QueryContext) ([]
 
 	return results, nil
 }
+
+type ingressRule struct {
+	k8s.CommonNamespacedFields
+	IngressClassName         *string
+	DefaultBackend           bool
+	Host                     string
+	Path                     string
+	PathType                 *v1.PathType
+	BackendServiceName       string
+	BackendServicePortName   string
+	BackendServicePortNumber int32
+	BackendResourceAPIGroup  *string
+	BackendResourceKind      string
+	BackendResourceName      string
+	TLS                      bool
+}
+
+// IngressRuleColumns returns kubernetes ingress rule fields as Osquery table columns.
+func IngressRuleColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&ingressRule{})
+}
+
+// getIngressClassName returns the ingress class from the spec, falling back to the deprecated annotation.
+func getIngressClassName(i v1.Ingress) *string {
+	if i.Spec.IngressClassName != nil {
+		return i.Spec.IngressClassName
+	}
+	if class, ok := i.Annotations[ingressClassAnnotation]; ok {
+		return &class
+	}
+	return nil
+}
+
+// matchesHost returns true if host is covered by the TLS host pattern. Wildcard patterns match a single label.
+func matchesHost(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*.") && host != "" {
+		parts := strings.SplitN(host, ".", 2)
+		return len(parts) == 2 && parts[1] == pattern[2:]
+	}
+	return false
+}
+
+// isTLSHost returns true if any of the ingress TLS entries covers the host.
+// TLS entries without hosts apply to all hosts of the ingress.
+func isTLSHost(tls []v1.IngressTLS, host string) bool {
+	for _, t := range tls {
+		if len(t.Hosts) == 0 {
+			return true
+		}
+		for _, h := range t.Hosts {
+			if matchesHost(h, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newIngressRule(i v1.Ingress, host string, backend v1.IngressBackend) *ingressRule {
+	item := &ingressRule{
+		CommonNamespacedFields: k8s.GetCommonNamespacedFields(i.ObjectMeta),
+		IngressClassName:       getIngressClassName(i),
+		Host:                   host,
+		TLS:                    isTLSHost(i.Spec.TLS, host),
+	}
+	if backend.Service != nil {
+		item.BackendServiceName = backend.Service.Name
+		item.BackendServicePortName = backend.Service.Port.Name
+		item.BackendServicePortNumber = backend.Service.Port.Number
+	}
+	if backend.Resource != nil {
+		item.BackendResourceAPIGroup = backend.Resource.APIGroup
+		item.BackendResourceKind = backend.Resource.Kind
+		item.BackendResourceName = backend.Resource.Name
+	}
+	return item
+}
+
+// IngressRulesGenerate generates one row per kubernetes ingress host, path and backend as Osquery table data.
+func IngressRulesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	ingresses, err := ListIngresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range ingresses {
+		if i.Spec.DefaultBackend != nil {
+			item := newIngressRule(i, "", *i.Spec.DefaultBackend)
+			item.DefaultBackend = true
+			results = append(results, k8s.ToMap(item))
+		}
+
+		for _, r := range i.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, p := range r.HTTP.Paths {
+				item := newIngressRule(i, r.Host, p.Backend)
+				item.Path = p.Path
+				item.PathType = p.PathType
+				results = append(results, k8s.ToMap(item))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+type ingressTLS struct {
+	k8s.CommonNamespacedFields
+	Host         string
+	SecretName   string
+	SecretExists bool
+}
+
+// IngressTLSColumns returns kubernetes ingress TLS fields as Osquery table columns.
+func IngressTLSColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&ingressTLS{})
+}
+
+// IngressTLSGenerate generates one row per kubernetes ingress TLS host and secret as Osquery table data.
+func IngressTLSGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	ingresses, err := ListIngresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]bool)
+	secretExists := func(namespace, name string) (bool, error) {
+		if name == "" {
+			return false, nil
+		}
+		key := namespace + "/" + name
+		if exists, ok := secrets[key]; ok {
+			return exists, nil
+		}
+		_, err := k8s.GetMetadataClient(ctx).Resource(corev1.SchemeGroupVersion.WithResource("secrets")).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		secrets[key] = err == nil
+		return err == nil, nil
+	}
+
+	for _, i := range ingresses {
+		for _, t := range i.Spec.TLS {
+			exists, err := secretExists(i.Namespace, t.SecretName)
+			if err != nil {
+				return nil, err
+			}
+
+			hosts := t.Hosts
+			if len(hosts) == 0 {
+				hosts = []string{""}
+			}
+			for _, h := range hosts {
+				item := &ingressTLS{
+					CommonNamespacedFields: k8s.GetCommonNamespacedFields(i.ObjectMeta),
+					Host:                   h,
+					SecretName:             t.SecretName,
+					SecretExists:           exists,
+				}
+				results = append(results, k8s.ToMap(item))
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,138 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package networking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesHost(t *testing.T) {
+	assert.True(t, matchesHost("example.com", "example.com"))
+	assert.True(t, matchesHost("*.example.com", "www.example.com"))
+	assert.False(t, matchesHost("*.example.com", "a.b.example.com"))
+	assert.False(t, matchesHost("*.example.com", "example.com"))
+	assert.False(t, matchesHost("*.example.com", ""))
+}
+
+func TestIngressRulesGenerate(t *testing.T) {
+	irs, err := IngressRulesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"backend_resource_api_group":  "k8s.example.com",
+			"backend_resource_kind":       "StorageBucket",
+			"backend_resource_name":       "static",
+			"backend_service_port_number": "0",
+			"cluster_uid":                 "c123",
+			"creation_timestamp":          "0",
+			"default_backend":             "1",
+			"ingress_class_name":          "nginx",
+			"name":                        "web",
+			"namespace":                   "default",
+			"tls":                         "0",
+			"uid":                         "i123",
+		},
+		{
+			"backend_service_name":        "web",
+			"backend_service_port_name":   "http",
+			"backend_service_port_number": "0",
+			"cluster_uid":                 "c123",
+			"creation_timestamp":          "0",
+			"default_backend":             "0",
+			"host":                        "www.example.com",
+			"ingress_class_name":          "nginx",
+			"name":                        "web",
+			"namespace":                   "default",
+			"path":                        "/",
+			"path_type":                   "Prefix",
+			"tls":                         "1",
+			"uid":                         "i123",
+		},
+		{
+			"backend_service_name":        "api",
+			"backend_service_port_number": "8080",
+			"cluster_uid":                 "c123",
+			"creation_timestamp":          "0",
+			"default_backend":             "0",
+			"host":                        "a.b.example.com",
+			"ingress_class_name":          "nginx",
+			"name":                        "web",
+			"namespace":                   "default",
+			"path":                        "/api",
+			"path_type":                   "Exact",
+			"tls":                         "0",
+			"uid":                         "i123",
+		},
+		{
+			"annotations":                 "{\"kubernetes.io/ingress.class\":\"traefik\"}",
+			"backend_service_name":        "legacy",
+			"backend_service_port_number": "80",
+			"cluster_uid":                 "c123",
+			"creation_timestamp":          "0",
+			"default_backend":             "0",
+			"ingress_class_name":          "traefik",
+			"name":                        "legacy",
+			"namespace":                   "n1",
+			"tls":                         "1",
+			"uid":                         "i456",
+		},
+	}, irs)
+}
+
+func TestIngressTLSGenerate(t *testing.T) {
+	its, err := IngressTLSGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"host":               "*.example.com",
+			"name":               "web",
+			"namespace":          "default",
+			"secret_exists":      "1",
+			"secret_name":        "example-tls",
+			"uid":                "i123",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"host":               "example.com",
+			"name":               "web",
+			"namespace":          "default",
+			"secret_exists":      "1",
+			"secret_name":        "example-tls",
+			"uid":                "i123",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"host":               "api.example.org",
+			"name":               "web",
+			"namespace":          "default",
+			"secret_exists":      "0",
+			"secret_name":        "missing-tls",
+			"uid":                "i123",
+		},
+		{
+			"annotations":        "{\"kubernetes.io/ingress.class\":\"traefik\"}",
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"name":               "legacy",
+			"namespace":          "n1",
+			"secret_exists":      "0",
+			"secret_name":        "legacy-tls",
+			"uid":                "i456",
+		},
+	}, its)
+}
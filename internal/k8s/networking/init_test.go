@@ -0,0 +1,178 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package networking
+
+import (
+	"github.com/Uptycs/kubequery/internal/k8s"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+func init() {
+	class := "nginx"
+	prefix := networkingv1.PathTypePrefix
+	exact := networkingv1.PathTypeExact
+	apiGroup := "k8s.example.com"
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			UID:       types.UID("i123"),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &class,
+			DefaultBackend: &networkingv1.IngressBackend{
+				Resource: &v1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "StorageBucket", Name: "static"},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"*.example.com", "example.com"}, SecretName: "example-tls"},
+				{Hosts: []string{"api.example.org"}, SecretName: "missing-tls"},
+			},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "www.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &prefix,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Name: "http"}},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Host: "a.b.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/api",
+									PathType: &exact,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{Name: "api", Port: networkingv1.ServiceBackendPort{Number: 8080}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	legacy := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "legacy",
+			Namespace:   "n1",
+			UID:         types.UID("i456"),
+			Annotations: map[string]string{"kubernetes.io/ingress.class": "traefik"},
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{SecretName: "legacy-tls"}},
+			Rules: []networkingv1.IngressRule{
+				{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{Name: "legacy", Port: networkingv1.ServiceBackendPort{Number: 80}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	secret := &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: "default", UID: types.UID("s123")},
+	}
+
+	udp := v1.ProtocolUDP
+	dns := intstr.FromInt(53)
+	http := intstr.FromString("http")
+	ephemeral := intstr.FromInt(32000)
+	ephemeralEnd := int32(32768)
+
+	denyAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "default", UID: types.UID("np1")},
+		Spec: networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+	allowWeb := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-web", Namespace: "default", UID: types.UID("np2")},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}}},
+						{
+							NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "ops"}},
+							PodSelector:       &metav1.LabelSelector{},
+						},
+					},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &http}},
+				},
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{
+						{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+					},
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: &udp, Port: &dns},
+						{Port: &ephemeral, EndPort: &ephemeralEnd},
+					},
+				},
+			},
+		},
+	}
+	allowAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-all", Namespace: "n1", UID: types.UID("np3")},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+		},
+	}
+
+	webPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", UID: types.UID("p1"), Labels: map[string]string{"app": "web"}},
+	}
+	dbPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "n1", UID: types.UID("p2"), Labels: map[string]string{"app": "db"}},
+	}
+	openPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache-1", Namespace: "n1", UID: types.UID("p3"), Labels: map[string]string{"app": "cache"}},
+	}
+
+	k8s.SetClient(fake.NewSimpleClientset(ingress, legacy, denyAll, allowWeb, allowAll, webPod, dbPod, openPod), types.UID("c123"))
+
+	scheme := runtime.NewScheme()
+	metav1.AddMetaToScheme(scheme)
+	k8s.SetMetadataClient(metadatafake.NewSimpleMetadataClient(scheme, secret))
+}
@@ -11,11 +11,26 @@ package networking
 
 import (
 	"context"
+	"sort"
 
 	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
 	"github.com/kolide/osquery-go/plugin/table"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	directionIngress = "ingress"
+	directionEgress  = "egress"
+
+	peerTypeAll       = "all"
+	peerTypePod       = "pod"
+	peerTypeNamespace = "namespace"
+	peerTypeIPBlock   = "ip_block"
 )
 
 type networkPolicy struct {
@@ -28,13 +43,35 @@ func NetworkPolicyColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&networkPolicy{})
 }
 
+// ListNetworkPolicies returns all kubernetes network policies in all namespaces.
+func ListNetworkPolicies(ctx context.Context) ([]v1.NetworkPolicy, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.NetworkPolicy, 0)
+
+	for {
+		nps, err := k8s.GetClient(ctx).NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, nps.Items...)
+
+		if nps.Continue == "" {
+			break
+		}
+		options.Continue = nps.Continue
+	}
+
+	return results, nil
+}
+
 // NetworkPoliciesGenerate generates the kubernetes network policies as Osquery table data.
 func NetworkPoliciesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	options := metav1.ListOptions{}
 	results := make([]map[string]string, 0)
 
 	for {
-		nps, err := k8s.GetClient().NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(context.TODO(), options)
+		nps, err := k8s.GetClient(ctx).NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
@@ -55,3 +92,185 @@ func NetworkPoliciesGenerate(ctx context.Context, queryContext table.QueryContex
 
 	return results, nil
 }
+
+type networkPolicyRule struct {
+	k8s.CommonNamespacedFields
+	Direction         string
+	RuleIndex         int
+	PeerType          string
+	PodSelector       *metav1.LabelSelector
+	NamespaceSelector *metav1.LabelSelector
+	IPBlockCIDR       string
+	IPBlockExcept     []string
+	Protocol          *corev1.Protocol
+	Port              string
+	EndPort           *int32
+}
+
+// NetworkPolicyRuleColumns returns kubernetes network policy rule fields as Osquery table columns.
+func NetworkPolicyRuleColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&networkPolicyRule{})
+}
+
+func getPeerType(peer v1.NetworkPolicyPeer) string {
+	if peer.IPBlock != nil {
+		return peerTypeIPBlock
+	}
+	if peer.NamespaceSelector != nil {
+		return peerTypeNamespace
+	}
+	return peerTypePod
+}
+
+// appendRuleRows appends one row per peer and port of the rule. Empty peers or ports match all.
+func appendRuleRows(results []map[string]string, np v1.NetworkPolicy, direction string, index int, peers []v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) []map[string]string {
+	if len(peers) == 0 {
+		peers = []v1.NetworkPolicyPeer{{}}
+	}
+	if len(ports) == 0 {
+		ports = []v1.NetworkPolicyPort{{}}
+	}
+
+	for _, peer := range peers {
+		for _, port := range ports {
+			item := &networkPolicyRule{
+				CommonNamespacedFields: k8s.GetCommonNamespacedFields(np.ObjectMeta),
+				Direction:              direction,
+				RuleIndex:              index,
+				PeerType:               peerTypeAll,
+				Protocol:               port.Protocol,
+				EndPort:                port.EndPort,
+			}
+			if peer.PodSelector != nil || peer.NamespaceSelector != nil || peer.IPBlock != nil {
+				item.PeerType = getPeerType(peer)
+				item.PodSelector = peer.PodSelector
+				item.NamespaceSelector = peer.NamespaceSelector
+			}
+			if peer.IPBlock != nil {
+				item.IPBlockCIDR = peer.IPBlock.CIDR
+				item.IPBlockExcept = peer.IPBlock.Except
+			}
+			if port.Port != nil {
+				item.Port = port.Port.String()
+			}
+			results = append(results, k8s.ToMap(item))
+		}
+	}
+
+	return results
+}
+
+// NetworkPolicyRulesGenerate generates one row per kubernetes network policy rule peer and port as Osquery table data.
+func NetworkPolicyRulesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	nps, err := ListNetworkPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, np := range nps {
+		for i, r := range np.Spec.Ingress {
+			results = appendRuleRows(results, np, directionIngress, i, r.From, r.Ports)
+		}
+		for i, r := range np.Spec.Egress {
+			results = appendRuleRows(results, np, directionEgress, i, r.To, r.Ports)
+		}
+	}
+
+	return results, nil
+}
+
+// getPolicyTypes returns whether the network policy applies to ingress and egress traffic.
+// When policy types are not specified, ingress is always assumed and egress only if there are egress rules.
+func getPolicyTypes(np v1.NetworkPolicy) (bool, bool) {
+	if len(np.Spec.PolicyTypes) == 0 {
+		return true, len(np.Spec.Egress) > 0
+	}
+
+	ingress, egress := false, false
+	for _, pt := range np.Spec.PolicyTypes {
+		switch pt {
+		case v1.PolicyTypeIngress:
+			ingress = true
+		case v1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return ingress, egress
+}
+
+// selectsPod returns true if the network policy pod selector selects the pod in the policy namespace.
+func selectsPod(np v1.NetworkPolicy, pod corev1.Pod) bool {
+	if np.Namespace != pod.Namespace {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+type podNetworkIsolation struct {
+	ClusterUID      types.UID
+	Namespace       string
+	PodName         string
+	PodUID          types.UID
+	IngressIsolated bool
+	EgressIsolated  bool
+	IngressPolicies []string
+	EgressPolicies  []string
+	FullyOpen       bool
+}
+
+// PodNetworkIsolationColumns returns kubernetes pod network isolation fields as Osquery table columns.
+func PodNetworkIsolationColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&podNetworkIsolation{})
+}
+
+// PodNetworkIsolationGenerate generates kubernetes pod network isolation as Osquery table data.
+// A pod is isolated for ingress or egress if any network policy of that type selects it.
+func PodNetworkIsolationGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	results := make([]map[string]string, 0)
+
+	nps, err := ListNetworkPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := workload.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range pods {
+		item := &podNetworkIsolation{
+			ClusterUID: k8s.GetClusterUID(),
+			Namespace:  p.Namespace,
+			PodName:    p.Name,
+			PodUID:     p.UID,
+		}
+
+		for _, np := range nps {
+			if !selectsPod(np, p) {
+				continue
+			}
+			ingress, egress := getPolicyTypes(np)
+			if ingress {
+				item.IngressIsolated = true
+				item.IngressPolicies = append(item.IngressPolicies, np.Name)
+			}
+			if egress {
+				item.EgressIsolated = true
+				item.EgressPolicies = append(item.EgressPolicies, np.Name)
+			}
+		}
+
+		sort.Strings(item.IngressPolicies)
+		sort.Strings(item.EgressPolicies)
+		item.FullyOpen = !item.IngressIsolated && !item.EgressIsolated
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package networking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkPolicyRulesGenerate(t *testing.T) {
+	nprs, err := NetworkPolicyRulesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"direction":          "ingress",
+			"name":               "allow-web",
+			"namespace":          "default",
+			"peer_type":          "pod",
+			"pod_selector":       "{\"matchLabels\":{\"app\":\"client\"}}",
+			"port":               "http",
+			"rule_index":         "0",
+			"uid":                "np2",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"direction":          "ingress",
+			"name":               "allow-web",
+			"namespace":          "default",
+			"namespace_selector": "{\"matchLabels\":{\"team\":\"ops\"}}",
+			"peer_type":          "namespace",
+			"pod_selector":       "{}",
+			"port":               "http",
+			"rule_index":         "0",
+			"uid":                "np2",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"direction":          "egress",
+			"ip_block_cidr":      "10.0.0.0/8",
+			"ip_block_except":    "[\"10.1.0.0/16\"]",
+			"name":               "allow-web",
+			"namespace":          "default",
+			"peer_type":          "ip_block",
+			"port":               "53",
+			"protocol":           "UDP",
+			"rule_index":         "0",
+			"uid":                "np2",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"direction":          "egress",
+			"end_port":           "32768",
+			"ip_block_cidr":      "10.0.0.0/8",
+			"ip_block_except":    "[\"10.1.0.0/16\"]",
+			"name":               "allow-web",
+			"namespace":          "default",
+			"peer_type":          "ip_block",
+			"port":               "32000",
+			"rule_index":         "0",
+			"uid":                "np2",
+		},
+		{
+			"cluster_uid":        "c123",
+			"creation_timestamp": "0",
+			"direction":          "ingress",
+			"name":               "allow-all",
+			"namespace":          "n1",
+			"peer_type":          "all",
+			"rule_index":         "0",
+			"uid":                "np3",
+		},
+	}, nprs)
+}
+
+func TestPodNetworkIsolationGenerate(t *testing.T) {
+	pnis, err := PodNetworkIsolationGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"cluster_uid":      "c123",
+			"egress_isolated":  "1",
+			"egress_policies":  "[\"allow-web\",\"deny-all\"]",
+			"fully_open":       "0",
+			"ingress_isolated": "1",
+			"ingress_policies": "[\"allow-web\",\"deny-all\"]",
+			"namespace":        "default",
+			"pod_name":         "web-1",
+			"pod_uid":          "p1",
+		},
+		{
+			"cluster_uid":      "c123",
+			"egress_isolated":  "0",
+			"fully_open":       "1",
+			"ingress_isolated": "0",
+			"namespace":        "n1",
+			"pod_name":         "cache-1",
+			"pod_uid":          "p3",
+		},
+		{
+			"cluster_uid":      "c123",
+			"egress_isolated":  "0",
+			"fully_open":       "0",
+			"ingress_isolated": "1",
+			"ingress_policies": "[\"allow-all\"]",
+			"namespace":        "n1",
+			"pod_name":         "db-1",
+			"pod_uid":          "p2",
+		},
+	}, pnis)
+}
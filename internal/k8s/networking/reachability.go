@@ -0,0 +1,371 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package networking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/core"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// errMissingSource is returned when the reachability table is queried without source constraints.
+var errMissingSource = errors.New("source_namespace and source_pod constraints are required")
+
+// endpoint is either a pod or a plain IP address outside of the cluster.
+type endpoint struct {
+	pod *corev1.Pod
+	ip  string
+}
+
+func (e endpoint) getIP() string {
+	if e.pod != nil {
+		return e.pod.Status.PodIP
+	}
+	return e.ip
+}
+
+// reachability evaluates network policies between endpoints.
+type reachability struct {
+	policies   []v1.NetworkPolicy
+	namespaces map[string]corev1.Namespace
+}
+
+func newReachability(policies []v1.NetworkPolicy, namespaces []corev1.Namespace) *reachability {
+	r := &reachability{
+		policies:   policies,
+		namespaces: make(map[string]corev1.Namespace, len(namespaces)),
+	}
+	for _, n := range namespaces {
+		r.namespaces[n.Name] = n
+	}
+	return r
+}
+
+func selectorMatches(selector *metav1.LabelSelector, set map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+// ipBlockMatches returns true if the IP is part of the CIDR and not part of any of the excluded CIDRs.
+func ipBlockMatches(block *v1.IPBlock, address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(ip) {
+		return false
+	}
+	for _, e := range block.Except {
+		_, except, err := net.ParseCIDR(e)
+		if err == nil && except.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// peerMatches returns true if the peer of a network policy in the specified namespace selects the endpoint.
+func (r *reachability) peerMatches(peer v1.NetworkPolicyPeer, namespace string, e endpoint) bool {
+	if peer.IPBlock != nil {
+		return ipBlockMatches(peer.IPBlock, e.getIP())
+	}
+	if e.pod == nil {
+		return false
+	}
+
+	if peer.NamespaceSelector != nil {
+		ns, ok := r.namespaces[e.pod.Namespace]
+		if !ok || !selectorMatches(peer.NamespaceSelector, ns.Labels) {
+			return false
+		}
+	} else if e.pod.Namespace != namespace {
+		return false
+	}
+
+	if peer.PodSelector != nil {
+		return selectorMatches(peer.PodSelector, e.pod.Labels)
+	}
+	return true
+}
+
+func (r *reachability) peersMatch(peers []v1.NetworkPolicyPeer, namespace string, e endpoint) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, p := range peers {
+		if r.peerMatches(p, namespace, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePort returns the container port number for a named port of the pod.
+func resolvePort(pod *corev1.Pod, name string, protocol corev1.Protocol) (int32, bool) {
+	if pod == nil {
+		return 0, false
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			proto := p.Protocol
+			if proto == "" {
+				proto = corev1.ProtocolTCP
+			}
+			if p.Name == name && proto == protocol {
+				return p.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// portsMatch returns true if any of the network policy ports allows traffic to the destination port.
+// Port 0 means any port of the protocol. Named ports are resolved against the destination pod.
+func portsMatch(ports []v1.NetworkPolicyPort, protocol corev1.Protocol, port int32, dst endpoint) bool {
+	if len(ports) == 0 {
+		return true
+	}
+
+	for _, p := range ports {
+		proto := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			proto = *p.Protocol
+		}
+		if proto != protocol {
+			continue
+		}
+		if p.Port == nil || port == 0 {
+			return true
+		}
+
+		if p.Port.Type == intstr.String {
+			if number, ok := resolvePort(dst.pod, p.Port.StrVal, protocol); ok && number == port {
+				return true
+			}
+			continue
+		}
+
+		if p.EndPort != nil {
+			if port >= p.Port.IntVal && port <= *p.EndPort {
+				return true
+			}
+		} else if port == p.Port.IntVal {
+			return true
+		}
+	}
+	return false
+}
+
+// isolatingPolicies returns the network policies selecting the pod for ingress or egress.
+func (r *reachability) isolatingPolicies(pod *corev1.Pod, ingress bool) []v1.NetworkPolicy {
+	results := make([]v1.NetworkPolicy, 0)
+	for _, np := range r.policies {
+		if !selectsPod(np, *pod) {
+			continue
+		}
+		in, eg := getPolicyTypes(np)
+		if (ingress && in) || (!ingress && eg) {
+			results = append(results, np)
+		}
+	}
+	return results
+}
+
+func policyNames(nps []v1.NetworkPolicy) []string {
+	names := make([]string, 0, len(nps))
+	for _, np := range nps {
+		names = append(names, np.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// egressAllowed returns whether the source pod is allowed to send traffic to the destination
+// and the network policies that isolate source pod egress.
+func (r *reachability) egressAllowed(src *corev1.Pod, dst endpoint, protocol corev1.Protocol, port int32) (bool, []string) {
+	nps := r.isolatingPolicies(src, false)
+	if len(nps) == 0 {
+		return true, nil
+	}
+
+	for _, np := range nps {
+		for _, rule := range np.Spec.Egress {
+			if r.peersMatch(rule.To, np.Namespace, dst) && portsMatch(rule.Ports, protocol, port, dst) {
+				return true, policyNames(nps)
+			}
+		}
+	}
+	return false, policyNames(nps)
+}
+
+// ingressAllowed returns whether the destination pod is allowed to receive traffic from the source
+// and the network policies that isolate destination pod ingress.
+func (r *reachability) ingressAllowed(src endpoint, dst *corev1.Pod, protocol corev1.Protocol, port int32) (bool, []string) {
+	nps := r.isolatingPolicies(dst, true)
+	if len(nps) == 0 {
+		return true, nil
+	}
+
+	for _, np := range nps {
+		for _, rule := range np.Spec.Ingress {
+			if r.peersMatch(rule.From, np.Namespace, src) && portsMatch(rule.Ports, protocol, port, endpoint{pod: dst}) {
+				return true, policyNames(nps)
+			}
+		}
+	}
+	return false, policyNames(nps)
+}
+
+type networkReachability struct {
+	ClusterUID           types.UID
+	SourceNamespace      string
+	SourcePod            string
+	SourceIP             string
+	DestinationNamespace string
+	DestinationPod       string
+	DestinationIP        string
+	Protocol             corev1.Protocol
+	Port                 string
+	EgressAllowed        bool
+	EgressPolicies       []string
+	IngressAllowed       bool
+	IngressPolicies      []string
+	Allowed              bool
+}
+
+// evaluate returns the reachability from source pod to destination endpoint. Traffic is allowed if it is
+// allowed as egress from the source and as ingress to the destination. Pods can always reach themselves.
+func (r *reachability) evaluate(src *corev1.Pod, dst endpoint, protocol corev1.Protocol, port int32) *networkReachability {
+	item := &networkReachability{
+		ClusterUID:      k8s.GetClusterUID(),
+		SourceNamespace: src.Namespace,
+		SourcePod:       src.Name,
+		SourceIP:        src.Status.PodIP,
+		DestinationIP:   dst.getIP(),
+		Protocol:        protocol,
+		IngressAllowed:  true,
+	}
+	if port != 0 {
+		item.Port = strconv.Itoa(int(port))
+	}
+	if dst.pod != nil {
+		item.DestinationNamespace = dst.pod.Namespace
+		item.DestinationPod = dst.pod.Name
+		if dst.pod.UID == src.UID {
+			item.EgressAllowed = true
+			item.Allowed = true
+			return item
+		}
+	}
+
+	item.EgressAllowed, item.EgressPolicies = r.egressAllowed(src, dst, protocol, port)
+	if dst.pod != nil {
+		item.IngressAllowed, item.IngressPolicies = r.ingressAllowed(endpoint{pod: src}, dst.pod, protocol, port)
+	}
+	item.Allowed = item.EgressAllowed && item.IngressAllowed
+	return item
+}
+
+// NetworkReachabilityColumns returns kubernetes network reachability fields as Osquery table columns.
+func NetworkReachabilityColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&networkReachability{})
+}
+
+// NetworkReachabilityGenerate generates kubernetes network reachability as Osquery table data.
+// source_namespace and source_pod constraints are required. Destination can be narrowed down with
+// destination_namespace, destination_pod and destination_ip constraints. Without port constraint,
+// traffic is allowed if any port of the protocol (TCP by default) is allowed.
+func NetworkReachabilityGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	srcNamespace, ok := k8s.GetConstraint(queryContext, "source_namespace")
+	if !ok {
+		return nil, errMissingSource
+	}
+	srcName, ok := k8s.GetConstraint(queryContext, "source_pod")
+	if !ok {
+		return nil, errMissingSource
+	}
+	dstNamespace, hasDstNamespace := k8s.GetConstraint(queryContext, "destination_namespace")
+	dstName, hasDstName := k8s.GetConstraint(queryContext, "destination_pod")
+	dstIP, hasDstIP := k8s.GetConstraint(queryContext, "destination_ip")
+
+	protocol := corev1.ProtocolTCP
+	if p, ok := k8s.GetConstraint(queryContext, "protocol"); ok {
+		protocol = corev1.Protocol(p)
+	}
+	port := int32(0)
+	if p, ok := k8s.GetConstraint(queryContext, "port"); ok {
+		number, err := strconv.ParseInt(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", p)
+		}
+		port = int32(number)
+	}
+
+	nps, err := ListNetworkPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := core.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := workload.ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var src *corev1.Pod
+	for i := range pods {
+		if pods[i].Namespace == srcNamespace && pods[i].Name == srcName {
+			src = &pods[i]
+			break
+		}
+	}
+	results := make([]map[string]string, 0)
+	if src == nil {
+		return results, nil
+	}
+
+	r := newReachability(nps, namespaces)
+	found := false
+	for i := range pods {
+		p := &pods[i]
+		if (hasDstNamespace && p.Namespace != dstNamespace) || (hasDstName && p.Name != dstName) || (hasDstIP && p.Status.PodIP != dstIP) {
+			continue
+		}
+		found = true
+		results = append(results, k8s.ToMap(r.evaluate(src, endpoint{pod: p}, protocol, port)))
+	}
+
+	// Destination IP that does not belong to any pod is treated as external to the cluster
+	if hasDstIP && !found && !hasDstNamespace && !hasDstName {
+		results = append(results, k8s.ToMap(r.evaluate(src, endpoint{ip: dstIP}, protocol, port)))
+	}
+
+	return results, nil
+}
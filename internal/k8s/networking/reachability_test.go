@@ -0,0 +1,472 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package networking
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Test model follows the network policy conformance tests: namespaces x, y, z each with pods a, b, c
+// serving TCP and UDP on ports 80 and 81.
+var (
+	testNamespaces = []corev1.Namespace{testNamespace("x"), testNamespace("y"), testNamespace("z")}
+	testPods       = map[string]*corev1.Pod{}
+)
+
+func init() {
+	ip := 1
+	for _, ns := range []string{"x", "y", "z"} {
+		for _, name := range []string{"a", "b", "c"} {
+			testPods[ns+"/"+name] = testPod(ns, name, fmt.Sprintf("10.0.0.%d", ip))
+			ip++
+		}
+	}
+}
+
+func testNamespace(name string) corev1.Namespace {
+	return corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"ns": name, "kubernetes.io/metadata.name": name}},
+	}
+}
+
+func testPod(namespace, name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(namespace + "/" + name),
+			Labels:    map[string]string{"pod": name},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "cont-80",
+					Ports: []corev1.ContainerPort{
+						{Name: "serve-80-tcp", ContainerPort: 80, Protocol: corev1.ProtocolTCP},
+						{Name: "serve-80-udp", ContainerPort: 80, Protocol: corev1.ProtocolUDP},
+					},
+				},
+				{
+					Name: "cont-81",
+					Ports: []corev1.ContainerPort{
+						{Name: "serve-81-tcp", ContainerPort: 81},
+						{Name: "serve-81-udp", ContainerPort: 81, Protocol: corev1.ProtocolUDP},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func testPolicy(namespace, name string, spec v1.NetworkPolicySpec) v1.NetworkPolicy {
+	return v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       spec,
+	}
+}
+
+func podSelector(name string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{"pod": name}}
+}
+
+func nsSelector(name string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{"ns": name}}
+}
+
+func ingressPolicy(namespace, name, pod string, rules ...v1.NetworkPolicyIngressRule) v1.NetworkPolicy {
+	return testPolicy(namespace, name, v1.NetworkPolicySpec{
+		PodSelector: *podSelector(pod),
+		PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+		Ingress:     rules,
+	})
+}
+
+func egressPolicy(namespace, name, pod string, rules ...v1.NetworkPolicyEgressRule) v1.NetworkPolicy {
+	return testPolicy(namespace, name, v1.NetworkPolicySpec{
+		PodSelector: *podSelector(pod),
+		PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+		Egress:      rules,
+	})
+}
+
+func tcpPort(port int) v1.NetworkPolicyPort {
+	p := intstr.FromInt(port)
+	return v1.NetworkPolicyPort{Port: &p}
+}
+
+type reachabilityCase struct {
+	from     string
+	to       string
+	protocol corev1.Protocol
+	port     int32
+	allowed  bool
+}
+
+func testReachability(t *testing.T, policies []v1.NetworkPolicy, cases []reachabilityCase) {
+	r := newReachability(policies, testNamespaces)
+	for _, c := range cases {
+		protocol := c.protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		dst := endpoint{ip: c.to}
+		if p, ok := testPods[c.to]; ok {
+			dst = endpoint{pod: p}
+		}
+		result := r.evaluate(testPods[c.from], dst, protocol, c.port)
+		assert.Equal(t, c.allowed, result.Allowed, "%s -> %s %s/%d", c.from, c.to, protocol, c.port)
+	}
+}
+
+func TestIPBlockMatches(t *testing.T) {
+	block := &v1.IPBlock{CIDR: "10.0.0.0/24", Except: []string{"10.0.0.128/25"}}
+	assert.True(t, ipBlockMatches(block, "10.0.0.1"))
+	assert.False(t, ipBlockMatches(block, "10.0.0.200"))
+	assert.False(t, ipBlockMatches(block, "10.0.1.1"))
+	assert.False(t, ipBlockMatches(block, ""))
+	assert.False(t, ipBlockMatches(&v1.IPBlock{CIDR: "invalid"}, "10.0.0.1"))
+	assert.True(t, ipBlockMatches(&v1.IPBlock{CIDR: "fd00::/8"}, "fd00::1"))
+}
+
+func TestReachabilityNoPolicies(t *testing.T) {
+	testReachability(t, nil, []reachabilityCase{
+		{from: "x/a", to: "x/b", allowed: true},
+		{from: "x/a", to: "y/b", port: 80, allowed: true},
+		{from: "z/c", to: "192.168.0.1", allowed: true},
+	})
+}
+
+func TestReachabilityDenyAllIngress(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "deny-ingress", v1.NetworkPolicySpec{PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress}}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: false},
+		{from: "y/a", to: "x/a", port: 80, allowed: false},
+		{from: "x/a", to: "x/a", allowed: true},
+		{from: "x/a", to: "y/a", allowed: true},
+	})
+}
+
+func TestReachabilityAllowAllIngress(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "allow-all", v1.NetworkPolicySpec{Ingress: []v1.NetworkPolicyIngressRule{{}}}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "z/c", to: "x/a", port: 81, allowed: true},
+	})
+}
+
+func TestReachabilityDenyAllEgress(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "deny-egress", v1.NetworkPolicySpec{PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress}}),
+	}, []reachabilityCase{
+		{from: "x/a", to: "y/a", allowed: false},
+		{from: "x/a", to: "x/b", allowed: false},
+		{from: "x/a", to: "8.8.8.8", allowed: false},
+		{from: "y/a", to: "x/a", allowed: true},
+	})
+}
+
+func TestReachabilityPolicyTypesDefault(t *testing.T) {
+	// Without policy types egress is only isolated if there are egress rules
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "egress-rules", v1.NetworkPolicySpec{
+			PodSelector: *podSelector("a"),
+			Egress:      []v1.NetworkPolicyEgressRule{{To: []v1.NetworkPolicyPeer{{PodSelector: podSelector("b")}}}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/a", to: "x/b", allowed: true},
+		{from: "x/a", to: "x/c", allowed: false},
+		{from: "x/b", to: "x/a", allowed: false},
+	})
+
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "egress-only", v1.NetworkPolicySpec{
+			PodSelector: *podSelector("a"),
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+			Ingress:     []v1.NetworkPolicyIngressRule{{From: []v1.NetworkPolicyPeer{{PodSelector: podSelector("b")}}}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/c", to: "x/a", allowed: true},
+		{from: "x/a", to: "x/c", allowed: false},
+	})
+}
+
+func TestReachabilityPodSelector(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-client-b", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{PodSelector: podSelector("b")}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "x/c", to: "x/a", allowed: false},
+		{from: "y/b", to: "x/a", allowed: false},
+		{from: "x/c", to: "x/b", allowed: true},
+	})
+}
+
+func TestReachabilityNamespaceSelector(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-ns-y", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: nsSelector("y")}},
+		}),
+	}, []reachabilityCase{
+		{from: "y/a", to: "x/a", allowed: true},
+		{from: "y/c", to: "x/a", allowed: true},
+		{from: "z/a", to: "x/a", allowed: false},
+		{from: "x/b", to: "x/a", allowed: false},
+	})
+}
+
+func TestReachabilityNamespaceSelectorMatchExpressions(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-ns-not-y", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "ns", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"y"}},
+				},
+			}}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "y/b", to: "x/a", allowed: false},
+		{from: "z/b", to: "x/a", allowed: true},
+	})
+}
+
+func TestReachabilityNamespaceAndPodSelector(t *testing.T) {
+	// Both selectors in the same peer select pods matching both
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-ns-y-pod-b", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: nsSelector("y"), PodSelector: podSelector("b")}},
+		}),
+	}, []reachabilityCase{
+		{from: "y/b", to: "x/a", allowed: true},
+		{from: "y/c", to: "x/a", allowed: false},
+		{from: "x/b", to: "x/a", allowed: false},
+		{from: "z/b", to: "x/a", allowed: false},
+	})
+}
+
+func TestReachabilityNamespaceOrPodSelector(t *testing.T) {
+	// Selectors in different peers select pods matching either
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-ns-y-or-pod-b", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: nsSelector("y")}, {PodSelector: podSelector("b")}},
+		}),
+	}, []reachabilityCase{
+		{from: "y/c", to: "x/a", allowed: true},
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "x/c", to: "x/a", allowed: false},
+		{from: "z/b", to: "x/a", allowed: false},
+	})
+}
+
+func TestReachabilityEmptyNamespaceSelector(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-pod-b-all-ns", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}, PodSelector: podSelector("b")}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "z/b", to: "x/a", allowed: true},
+		{from: "z/c", to: "x/a", allowed: false},
+	})
+}
+
+func TestReachabilityPorts(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-port-80", "a", v1.NetworkPolicyIngressRule{
+			Ports: []v1.NetworkPolicyPort{tcpPort(80)},
+		}),
+	}, []reachabilityCase{
+		{from: "y/b", to: "x/a", port: 80, allowed: true},
+		{from: "y/b", to: "x/a", port: 81, allowed: false},
+		{from: "y/b", to: "x/a", allowed: true},
+		{from: "y/b", to: "x/a", protocol: corev1.ProtocolUDP, port: 80, allowed: false},
+	})
+}
+
+func TestReachabilityNamedPort(t *testing.T) {
+	named := intstr.FromString("serve-81-tcp")
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-named-port", "a", v1.NetworkPolicyIngressRule{
+			Ports: []v1.NetworkPolicyPort{{Port: &named}},
+		}),
+		egressPolicy("y", "allow-named-port", "a", v1.NetworkPolicyEgressRule{
+			Ports: []v1.NetworkPolicyPort{{Port: &named}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", port: 81, allowed: true},
+		{from: "x/b", to: "x/a", port: 80, allowed: false},
+		{from: "y/a", to: "x/b", port: 81, allowed: true},
+		{from: "y/a", to: "x/b", port: 80, allowed: false},
+		{from: "y/a", to: "192.168.0.1", port: 81, allowed: false},
+	})
+}
+
+func TestReachabilityProtocol(t *testing.T) {
+	udp := corev1.ProtocolUDP
+	port := intstr.FromInt(80)
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("x", "allow-udp-80", "a", v1.NetworkPolicyIngressRule{
+			Ports: []v1.NetworkPolicyPort{{Protocol: &udp, Port: &port}},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", protocol: corev1.ProtocolUDP, port: 80, allowed: true},
+		{from: "x/b", to: "x/a", protocol: corev1.ProtocolUDP, port: 81, allowed: false},
+		{from: "x/b", to: "x/a", port: 80, allowed: false},
+		{from: "x/b", to: "x/a", allowed: false},
+	})
+}
+
+func TestReachabilityEndPort(t *testing.T) {
+	port := tcpPort(80)
+	end := int32(82)
+	port.EndPort = &end
+	testReachability(t, []v1.NetworkPolicy{
+		egressPolicy("x", "allow-port-range", "a", v1.NetworkPolicyEgressRule{
+			Ports: []v1.NetworkPolicyPort{port},
+		}),
+	}, []reachabilityCase{
+		{from: "x/a", to: "y/a", port: 79, allowed: false},
+		{from: "x/a", to: "y/a", port: 80, allowed: true},
+		{from: "x/a", to: "y/a", port: 81, allowed: true},
+		{from: "x/a", to: "y/a", port: 82, allowed: true},
+		{from: "x/a", to: "y/a", port: 83, allowed: false},
+	})
+}
+
+func TestReachabilityIPBlock(t *testing.T) {
+	testReachability(t, []v1.NetworkPolicy{
+		egressPolicy("x", "allow-cidr", "a", v1.NetworkPolicyEgressRule{
+			To: []v1.NetworkPolicyPeer{{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/29", Except: []string{"10.0.0.4/30"}}}},
+		}),
+		ingressPolicy("z", "allow-from-cidr", "c", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{IPBlock: &v1.IPBlock{CIDR: "10.0.0.2/32"}}},
+		}),
+	}, []reachabilityCase{
+		// Pod IPs are assigned in order: x/a is 10.0.0.1, x/b is 10.0.0.2 ... z/c is 10.0.0.9
+		{from: "x/a", to: "x/b", allowed: true},
+		{from: "x/a", to: "x/c", allowed: true},
+		{from: "x/a", to: "y/a", allowed: false},
+		{from: "x/a", to: "z/a", allowed: false},
+		{from: "x/a", to: "z/b", allowed: false},
+		{from: "x/a", to: "10.0.0.3", allowed: true},
+		{from: "x/a", to: "192.168.0.1", allowed: false},
+		{from: "x/b", to: "z/c", allowed: true},
+		{from: "y/a", to: "z/c", allowed: false},
+	})
+}
+
+func TestReachabilityIngressAndEgress(t *testing.T) {
+	// Traffic must be allowed by both the source egress and the destination ingress policies
+	testReachability(t, []v1.NetworkPolicy{
+		egressPolicy("x", "allow-to-y", "a", v1.NetworkPolicyEgressRule{
+			To: []v1.NetworkPolicyPeer{{NamespaceSelector: nsSelector("y")}},
+		}),
+		ingressPolicy("y", "allow-from-x-a", "b", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{NamespaceSelector: nsSelector("x"), PodSelector: podSelector("a")}},
+		}),
+		ingressPolicy("y", "deny-c", "c"),
+	}, []reachabilityCase{
+		{from: "x/a", to: "y/a", allowed: true},
+		{from: "x/a", to: "y/b", allowed: true},
+		{from: "x/a", to: "y/c", allowed: false},
+		{from: "x/a", to: "z/a", allowed: false},
+		{from: "x/b", to: "y/b", allowed: false},
+	})
+}
+
+func TestReachabilityMultiplePolicies(t *testing.T) {
+	// Policies are additive
+	testReachability(t, []v1.NetworkPolicy{
+		testPolicy("x", "deny-all", v1.NetworkPolicySpec{PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress}}),
+		ingressPolicy("x", "allow-b", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{PodSelector: podSelector("b")}},
+		}),
+		ingressPolicy("x", "allow-c-81", "a", v1.NetworkPolicyIngressRule{
+			From:  []v1.NetworkPolicyPeer{{PodSelector: podSelector("c")}},
+			Ports: []v1.NetworkPolicyPort{tcpPort(81)},
+		}),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", port: 80, allowed: true},
+		{from: "x/c", to: "x/a", port: 80, allowed: false},
+		{from: "x/c", to: "x/a", port: 81, allowed: true},
+		{from: "x/a", to: "x/b", allowed: false},
+	})
+}
+
+func TestReachabilityOtherNamespacePolicy(t *testing.T) {
+	// Policies only select pods in their own namespace
+	testReachability(t, []v1.NetworkPolicy{
+		ingressPolicy("y", "deny-a", "a"),
+	}, []reachabilityCase{
+		{from: "x/b", to: "x/a", allowed: true},
+		{from: "x/b", to: "y/a", allowed: false},
+	})
+}
+
+func TestReachabilityPolicies(t *testing.T) {
+	r := newReachability([]v1.NetworkPolicy{
+		testPolicy("x", "deny-all", v1.NetworkPolicySpec{PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress}}),
+		ingressPolicy("x", "allow-b", "a", v1.NetworkPolicyIngressRule{
+			From: []v1.NetworkPolicyPeer{{PodSelector: podSelector("b")}},
+		}),
+	}, testNamespaces)
+
+	result := r.evaluate(testPods["x/b"], endpoint{pod: testPods["x/a"]}, corev1.ProtocolTCP, 80)
+	assert.False(t, result.EgressAllowed)
+	assert.Equal(t, []string{"deny-all"}, result.EgressPolicies)
+	assert.True(t, result.IngressAllowed)
+	assert.Equal(t, []string{"allow-b", "deny-all"}, result.IngressPolicies)
+	assert.False(t, result.Allowed)
+}
+
+func TestNetworkReachabilityGenerate(t *testing.T) {
+	_, err := NetworkReachabilityGenerate(context.TODO(), table.QueryContext{})
+	assert.Equal(t, errMissingSource, err)
+
+	nrs, err := NetworkReachabilityGenerate(context.TODO(), table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"source_namespace":      {Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "n1"}}},
+			"source_pod":            {Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "cache-1"}}},
+			"destination_namespace": {Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "default"}}},
+			"port":                  {Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "8080"}}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"allowed":               "0",
+			"cluster_uid":           "c123",
+			"destination_namespace": "default",
+			"destination_pod":       "web-1",
+			"egress_allowed":        "1",
+			"ingress_allowed":       "0",
+			"ingress_policies":      "[\"allow-web\",\"deny-all\"]",
+			"port":                  "8080",
+			"protocol":              "TCP",
+			"source_namespace":      "n1",
+			"source_pod":            "cache-1",
+		},
+	}, nrs)
+}
@@ -28,13 +28,35 @@ func PodSecurityPolicyColumns() []table.ColumnDefinition {
 	return k8s.GetSchema(&podSecurityPolicy{})
 }
 
+// ListPodSecurityPolicies returns all kubernetes pod security policies.
+func ListPodSecurityPolicies(ctx context.Context) ([]v1beta1.PodSecurityPolicy, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1beta1.PodSecurityPolicy, 0)
+
+	for {
+		psps, err := k8s.GetClient(ctx).PolicyV1beta1().PodSecurityPolicies().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, psps.Items...)
+
+		if psps.Continue == "" {
+			break
+		}
+		options.Continue = psps.Continue
+	}
+
+	return results, nil
+}
+
 // PodSecurityPoliciesGenerate generates the kubernetes pod security policies as Osquery table data.
 func PodSecurityPoliciesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	options := metav1.ListOptions{}
 	results := make([]map[string]string, 0)
 
 	for {
-		psps, err := k8s.GetClient().PolicyV1beta1().PodSecurityPolicies().List(context.TODO(), options)
+		psps, err := k8s.GetClient(ctx).PolicyV1beta1().PodSecurityPolicies().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
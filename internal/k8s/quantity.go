@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package k8s
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	quantityType             = reflect.TypeOf(resource.Quantity{})
+	resourceListType         = reflect.TypeOf(v1.ResourceList{})
+	resourceRequirementsType = reflect.TypeOf(v1.ResourceRequirements{})
+)
+
+// quantityResource is a resource that gets a numeric column in addition to the JSON of resource lists.
+type quantityResource struct {
+	name v1.ResourceName
+	unit string
+}
+
+var quantityResources = []quantityResource{
+	{name: v1.ResourceCPU, unit: "millicores"},
+	{name: v1.ResourceMemory, unit: "bytes"},
+	{name: v1.ResourceEphemeralStorage, unit: "bytes"},
+	{name: v1.ResourceStorage, unit: "bytes"},
+}
+
+// listResources limits the numeric columns of resource list fields that can only contain some of the resources.
+// Other fields get columns for all resources: capacity is used by both nodes and persistent volumes, limit ranges
+// and quotas apply to both pods and persistent volume claims.
+var listResources = map[string]map[v1.ResourceName]bool{
+	"allocatable": {v1.ResourceCPU: true, v1.ResourceMemory: true, v1.ResourceEphemeralStorage: true},
+	"overhead":    {v1.ResourceCPU: true, v1.ResourceMemory: true},
+}
+
+// ratioFields are resource lists of ratios instead of quantities.
+var ratioFields = map[string]bool{
+	"max_limit_request_ratio": true,
+}
+
+// quotaFields are resource quota lists where compute resources can be set separately for requests and limits.
+var quotaFields = map[string]bool{
+	"hard":        true,
+	"status_hard": true,
+	"status_used": true,
+}
+
+func (r quantityResource) key() string {
+	return strings.ReplaceAll(string(r.name), "-", "_")
+}
+
+// value returns CPU in millicores and other resources in base units rounded up.
+func (r quantityResource) value(q resource.Quantity) string {
+	if r.name == v1.ResourceCPU {
+		return strconv.FormatInt(q.MilliValue(), 10)
+	}
+	return strconv.FormatInt(q.Value(), 10)
+}
+
+// lookup returns the quantity of the resource in the list.
+func (r quantityResource) lookup(list v1.ResourceList) (resource.Quantity, bool) {
+	q, ok := list[r.name]
+	return q, ok
+}
+
+// lookupQuotaRequest returns the requests quota of the resource. Resource quotas can use requests. prefix for
+// compute and storage resources, which is the same as the resource without prefix.
+func (r quantityResource) lookupQuotaRequest(list v1.ResourceList) (resource.Quantity, bool) {
+	if q, ok := list[r.name]; ok {
+		return q, true
+	}
+	q, ok := list[v1.ResourceName("requests."+string(r.name))]
+	return q, ok
+}
+
+// lookupQuotaLimit returns the limits quota of the resource set with limits. prefix.
+func (r quantityResource) lookupQuotaLimit(list v1.ResourceList) (resource.Quantity, bool) {
+	q, ok := list[v1.ResourceName("limits."+string(r.name))]
+	return q, ok
+}
+
+// getListResources returns the resources that get numeric columns for the resource list field.
+func getListResources(key string) []quantityResource {
+	allowed, ok := listResources[key]
+	if !ok {
+		return quantityResources
+	}
+
+	results := make([]quantityResource, 0, len(allowed))
+	for _, r := range quantityResources {
+		if allowed[r.name] {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func isQuantity(tp reflect.Type) bool {
+	return tp == quantityType || (tp.Kind() == reflect.Ptr && tp.Elem() == quantityType)
+}
+
+// getQuantityValue returns the value of quantity fields in base units rounded up. Quantity fields outside of
+// resource lists are storage sizes, like empty dir size limit and CSI storage capacity, so the value is in bytes.
+// CPU is only set in resource lists, which are converted to millicores.
+func getQuantityValue(field reflect.Value) string {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	q := field.Interface().(resource.Quantity)
+	return strconv.FormatInt(q.Value(), 10)
+}
+
+// getQuantitySchema returns the numeric columns of resource list and resource requirements fields.
+// Resource list columns are prefixed with the field name. Resource requirements columns are
+// named <resource>_request_<unit> and <resource>_limit_<unit>. Resource quota lists have both
+// request and limit columns prefixed with the field name. Resource requirements of containers and
+// persistent volume claims have the same type, so they get columns for all resources.
+func getQuantitySchema(key string, tp reflect.Type) []table.ColumnDefinition {
+	schema := make([]table.ColumnDefinition, 0)
+
+	switch tp {
+	case resourceListType:
+		if ratioFields[key] {
+			break
+		}
+		for _, r := range getListResources(key) {
+			if quotaFields[key] {
+				schema = append(schema,
+					table.BigIntColumn(key+"_"+r.key()+"_request_"+r.unit),
+					table.BigIntColumn(key+"_"+r.key()+"_limit_"+r.unit),
+				)
+			} else {
+				schema = append(schema, table.BigIntColumn(key+"_"+r.key()+"_"+r.unit))
+			}
+		}
+	case resourceRequirementsType:
+		for _, r := range quantityResources {
+			schema = append(schema,
+				table.BigIntColumn(r.key()+"_request_"+r.unit),
+				table.BigIntColumn(r.key()+"_limit_"+r.unit),
+			)
+		}
+	}
+
+	return schema
+}
+
+func addResourceListValues(item map[string]string, prefix, suffix string, resources []quantityResource, list v1.ResourceList,
+	lookup func(quantityResource, v1.ResourceList) (resource.Quantity, bool)) {
+	for _, r := range resources {
+		if q, ok := lookup(r, list); ok {
+			item[prefix+r.key()+suffix+r.unit] = r.value(q)
+		}
+	}
+}
+
+// addQuantityValues adds the numeric values of resource list and resource requirements fields to the item.
+// Resources missing from the list are omitted.
+func addQuantityValues(item map[string]string, key string, field reflect.Value) {
+	switch field.Type() {
+	case resourceListType:
+		if ratioFields[key] {
+			break
+		}
+		list := field.Interface().(v1.ResourceList)
+		resources := getListResources(key)
+		if quotaFields[key] {
+			addResourceListValues(item, key+"_", "_request_", resources, list, quantityResource.lookupQuotaRequest)
+			addResourceListValues(item, key+"_", "_limit_", resources, list, quantityResource.lookupQuotaLimit)
+		} else {
+			addResourceListValues(item, key+"_", "_", resources, list, quantityResource.lookup)
+		}
+	case resourceRequirementsType:
+		rr := field.Interface().(v1.ResourceRequirements)
+		addResourceListValues(item, "", "_request_", quantityResources, rr.Requests, quantityResource.lookup)
+		addResourceListValues(item, "", "_limit_", quantityResources, rr.Limits, quantityResource.lookup)
+	}
+}
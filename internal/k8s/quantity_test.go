@@ -0,0 +1,113 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type quantityTest struct {
+	SizeLimit            *resource.Quantity
+	Capacity             v1.ResourceList
+	Overhead             v1.ResourceList
+	Hard                 v1.ResourceList
+	MaxLimitRequestRatio v1.ResourceList
+	Resources            v1.ResourceRequirements
+}
+
+func TestQuantitySchema(t *testing.T) {
+	assert.Equal(t, []table.ColumnDefinition{
+		table.BigIntColumn("size_limit"),
+		table.TextColumn("capacity"),
+		table.BigIntColumn("capacity_cpu_millicores"),
+		table.BigIntColumn("capacity_memory_bytes"),
+		table.BigIntColumn("capacity_ephemeral_storage_bytes"),
+		table.BigIntColumn("capacity_storage_bytes"),
+		table.TextColumn("overhead"),
+		table.BigIntColumn("overhead_cpu_millicores"),
+		table.BigIntColumn("overhead_memory_bytes"),
+		table.TextColumn("hard"),
+		table.BigIntColumn("hard_cpu_request_millicores"),
+		table.BigIntColumn("hard_cpu_limit_millicores"),
+		table.BigIntColumn("hard_memory_request_bytes"),
+		table.BigIntColumn("hard_memory_limit_bytes"),
+		table.BigIntColumn("hard_ephemeral_storage_request_bytes"),
+		table.BigIntColumn("hard_ephemeral_storage_limit_bytes"),
+		table.BigIntColumn("hard_storage_request_bytes"),
+		table.BigIntColumn("hard_storage_limit_bytes"),
+		table.TextColumn("max_limit_request_ratio"),
+		table.TextColumn("resources"),
+		table.BigIntColumn("cpu_request_millicores"),
+		table.BigIntColumn("cpu_limit_millicores"),
+		table.BigIntColumn("memory_request_bytes"),
+		table.BigIntColumn("memory_limit_bytes"),
+		table.BigIntColumn("ephemeral_storage_request_bytes"),
+		table.BigIntColumn("ephemeral_storage_limit_bytes"),
+		table.BigIntColumn("storage_request_bytes"),
+		table.BigIntColumn("storage_limit_bytes"),
+	}, GetSchema(&quantityTest{}))
+}
+
+func TestQuantityToMap(t *testing.T) {
+	size := resource.MustParse("1Gi")
+	assert.Equal(t, map[string]string{
+		"size_limit":                  "1073741824",
+		"capacity":                    "{\"cpu\":\"2\",\"memory\":\"1Gi\"}",
+		"capacity_cpu_millicores":     "2000",
+		"capacity_memory_bytes":       "1073741824",
+		"overhead":                    "{\"cpu\":\"100m\",\"ephemeral-storage\":\"1Gi\"}",
+		"overhead_cpu_millicores":     "100",
+		"hard":                        "{\"limits.cpu\":\"4\",\"requests.cpu\":\"1500m\",\"requests.storage\":\"10G\"}",
+		"hard_cpu_request_millicores": "1500",
+		"hard_cpu_limit_millicores":   "4000",
+		"hard_storage_request_bytes":  "10000000000",
+		"max_limit_request_ratio":     "{\"cpu\":\"2\"}",
+		"resources":                   "{\"limits\":{\"cpu\":\"250m\",\"memory\":\"64Mi\"},\"requests\":{\"cpu\":\"100m\"}}",
+		"cpu_request_millicores":      "100",
+		"cpu_limit_millicores":        "250",
+		"memory_limit_bytes":          "67108864",
+	}, ToMap(&quantityTest{
+		SizeLimit: &size,
+		Capacity:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi")},
+		Overhead:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+		Hard: v1.ResourceList{
+			"requests.cpu":     resource.MustParse("1500m"),
+			"limits.cpu":       resource.MustParse("4"),
+			"requests.storage": resource.MustParse("10G"),
+		},
+		MaxLimitRequestRatio: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("0.1")},
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+		},
+	}))
+
+	assert.Equal(t, map[string]string{"resources": "{}"}, ToMap(&quantityTest{}))
+}
+
+func TestQuotaToMap(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"hard":                      "{\"limits.memory\":\"2Gi\",\"memory\":\"1Gi\",\"pods\":\"10\"}",
+		"hard_memory_request_bytes": "1073741824",
+		"hard_memory_limit_bytes":   "2147483648",
+		"resources":                 "{}",
+	}, ToMap(&quantityTest{
+		Hard: v1.ResourceList{
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+			"limits.memory":   resource.MustParse("2Gi"),
+			v1.ResourcePods:   resource.MustParse("10"),
+		},
+	}))
+}
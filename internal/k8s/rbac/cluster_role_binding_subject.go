@@ -37,7 +37,7 @@ func ClusterRoleBindingSubjectsGenerate(ctx context.Context, queryContext table.
 	results := make([]map[string]string, 0)
 
 	for {
-		crbs, err := k8s.GetClient().RbacV1().ClusterRoleBindings().List(context.TODO(), options)
+		crbs, err := k8s.GetClient(ctx).RbacV1().ClusterRoleBindings().List(context.TODO(), options)
 		if err != nil {
 			return nil, err
 		}
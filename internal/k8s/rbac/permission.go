@@ -0,0 +1,414 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/core"
+	"github.com/kolide/osquery-go/plugin/table"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	groupAuthenticated   = "system:authenticated"
+	groupServiceAccounts = "system:serviceaccounts"
+)
+
+// role is either a Role or a ClusterRole with aggregated rules resolved.
+type role struct {
+	kind      string
+	name      string
+	namespace string
+	uid       types.UID
+	rules     []v1.PolicyRule
+}
+
+// binding is either a RoleBinding or a ClusterRoleBinding.
+type binding struct {
+	kind      string
+	name      string
+	namespace string
+	uid       types.UID
+	roleRef   v1.RoleRef
+	subjects  []v1.Subject
+}
+
+// grant is a role granted to a subject through a binding. Empty namespace means the grant is cluster-wide.
+// Service accounts that are members of a bound group are granted the role via that group.
+type grant struct {
+	subject   v1.Subject
+	viaGroup  string
+	namespace string
+	binding   *binding
+	role      *role
+}
+
+// policy resolves RBAC bindings to roles the same way RBAC authorizer does.
+type policy struct {
+	roles           map[string]*role
+	bindings        []*binding
+	serviceAccounts []corev1.ServiceAccount
+}
+
+func roleKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func ruleKey(r v1.PolicyRule) string {
+	bytes, _ := json.Marshal(r)
+	return string(bytes)
+}
+
+// aggregateRules returns the rules of the cluster role including rules of all cluster roles selected by its aggregation rule.
+func aggregateRules(cr v1.ClusterRole, clusterRoles []v1.ClusterRole, visited map[string]bool) []v1.PolicyRule {
+	visited[cr.Name] = true
+	rules := append([]v1.PolicyRule{}, cr.Rules...)
+	if cr.AggregationRule == nil {
+		return rules
+	}
+
+	for _, s := range cr.AggregationRule.ClusterRoleSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&s)
+		if err != nil {
+			continue
+		}
+		for _, other := range clusterRoles {
+			if visited[other.Name] || !selector.Matches(labels.Set(other.Labels)) {
+				continue
+			}
+			rules = append(rules, aggregateRules(other, clusterRoles, visited)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	results := make([]v1.PolicyRule, 0, len(rules))
+	for _, r := range rules {
+		key := ruleKey(r)
+		if !seen[key] {
+			seen[key] = true
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func newPolicy(roles []v1.Role, clusterRoles []v1.ClusterRole, roleBindings []v1.RoleBinding, clusterRoleBindings []v1.ClusterRoleBinding, sas []corev1.ServiceAccount) *policy {
+	p := &policy{
+		roles:           make(map[string]*role),
+		bindings:        make([]*binding, 0, len(roleBindings)+len(clusterRoleBindings)),
+		serviceAccounts: sas,
+	}
+
+	for _, r := range roles {
+		p.roles[roleKey("Role", r.Namespace, r.Name)] = &role{
+			kind:      "Role",
+			name:      r.Name,
+			namespace: r.Namespace,
+			uid:       r.UID,
+			rules:     r.Rules,
+		}
+	}
+	for _, cr := range clusterRoles {
+		p.roles[roleKey("ClusterRole", "", cr.Name)] = &role{
+			kind:  "ClusterRole",
+			name:  cr.Name,
+			uid:   cr.UID,
+			rules: aggregateRules(cr, clusterRoles, make(map[string]bool)),
+		}
+	}
+
+	for _, crb := range clusterRoleBindings {
+		p.bindings = append(p.bindings, &binding{
+			kind:     "ClusterRoleBinding",
+			name:     crb.Name,
+			uid:      crb.UID,
+			roleRef:  crb.RoleRef,
+			subjects: crb.Subjects,
+		})
+	}
+	for _, rb := range roleBindings {
+		p.bindings = append(p.bindings, &binding{
+			kind:      "RoleBinding",
+			name:      rb.Name,
+			namespace: rb.Namespace,
+			uid:       rb.UID,
+			roleRef:   rb.RoleRef,
+			subjects:  rb.Subjects,
+		})
+	}
+
+	return p
+}
+
+// getRole returns the role referenced by the binding. RoleBindings can reference ClusterRoles.
+func (p *policy) getRole(b *binding) *role {
+	namespace := ""
+	if b.roleRef.Kind == "Role" {
+		namespace = b.namespace
+	}
+	return p.roles[roleKey(b.roleRef.Kind, namespace, b.roleRef.Name)]
+}
+
+// groupServiceAccounts returns the service accounts that are members of the group.
+func (p *policy) groupServiceAccounts(group string) []corev1.ServiceAccount {
+	results := make([]corev1.ServiceAccount, 0)
+	for _, sa := range p.serviceAccounts {
+		if group == groupAuthenticated || group == groupServiceAccounts || group == groupServiceAccounts+":"+sa.Namespace {
+			results = append(results, sa)
+		}
+	}
+	return results
+}
+
+// grants returns all grants of all bindings. Bindings referencing roles that do not exist are ignored.
+func (p *policy) grants() []grant {
+	results := make([]grant, 0)
+	for _, b := range p.bindings {
+		r := p.getRole(b)
+		if r == nil {
+			continue
+		}
+
+		for _, s := range b.subjects {
+			if s.Kind == v1.ServiceAccountKind && s.Namespace == "" {
+				s.Namespace = b.namespace
+			}
+			results = append(results, grant{subject: s, namespace: b.namespace, binding: b, role: r})
+
+			if s.Kind != v1.GroupKind {
+				continue
+			}
+			for _, sa := range p.groupServiceAccounts(s.Name) {
+				results = append(results, grant{
+					subject:   v1.Subject{Kind: v1.ServiceAccountKind, Name: sa.Name, Namespace: sa.Namespace},
+					viaGroup:  s.Name,
+					namespace: b.namespace,
+					binding:   b,
+					role:      r,
+				})
+			}
+		}
+	}
+	return results
+}
+
+// ListRoles returns all kubernetes roles in all namespaces.
+func ListRoles(ctx context.Context) ([]v1.Role, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Role, 0)
+
+	for {
+		rs, err := k8s.GetClient(ctx).RbacV1().Roles(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rs.Items...)
+
+		if rs.Continue == "" {
+			break
+		}
+		options.Continue = rs.Continue
+	}
+
+	return results, nil
+}
+
+// ListClusterRoles returns all kubernetes cluster roles.
+func ListClusterRoles(ctx context.Context) ([]v1.ClusterRole, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.ClusterRole, 0)
+
+	for {
+		crs, err := k8s.GetClient(ctx).RbacV1().ClusterRoles().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, crs.Items...)
+
+		if crs.Continue == "" {
+			break
+		}
+		options.Continue = crs.Continue
+	}
+
+	return results, nil
+}
+
+// ListRoleBindings returns all kubernetes role bindings in all namespaces.
+func ListRoleBindings(ctx context.Context) ([]v1.RoleBinding, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.RoleBinding, 0)
+
+	for {
+		rbs, err := k8s.GetClient(ctx).RbacV1().RoleBindings(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rbs.Items...)
+
+		if rbs.Continue == "" {
+			break
+		}
+		options.Continue = rbs.Continue
+	}
+
+	return results, nil
+}
+
+// ListClusterRoleBindings returns all kubernetes cluster role bindings.
+func ListClusterRoleBindings(ctx context.Context) ([]v1.ClusterRoleBinding, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.ClusterRoleBinding, 0)
+
+	for {
+		crbs, err := k8s.GetClient(ctx).RbacV1().ClusterRoleBindings().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, crbs.Items...)
+
+		if crbs.Continue == "" {
+			break
+		}
+		options.Continue = crbs.Continue
+	}
+
+	return results, nil
+}
+
+// loadPolicy lists all RBAC objects and service accounts and resolves them.
+func loadPolicy(ctx context.Context) (*policy, error) {
+	roles, err := ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterRoles, err := ListClusterRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleBindings, err := ListRoleBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings, err := ListClusterRoleBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sas, err := core.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPolicy(roles, clusterRoles, roleBindings, clusterRoleBindings, sas), nil
+}
+
+type rbacPermission struct {
+	ClusterUID       types.UID
+	SubjectKind      string
+	SubjectName      string
+	SubjectNamespace string
+	ViaGroup         string
+	Namespace        string
+	APIGroup         string
+	Resource         string
+	NonResourceURL   string
+	Verb             string
+	ResourceNames    []string
+	BindingKind      string
+	BindingName      string
+	BindingNamespace string
+	BindingUID       types.UID
+	RoleKind         string
+	RoleName         string
+	RoleUID          types.UID
+}
+
+func newRBACPermission(g grant) *rbacPermission {
+	return &rbacPermission{
+		ClusterUID:       k8s.GetClusterUID(),
+		SubjectKind:      g.subject.Kind,
+		SubjectName:      g.subject.Name,
+		SubjectNamespace: g.subject.Namespace,
+		ViaGroup:         g.viaGroup,
+		Namespace:        g.namespace,
+		BindingKind:      g.binding.kind,
+		BindingName:      g.binding.name,
+		BindingNamespace: g.binding.namespace,
+		BindingUID:       g.binding.uid,
+		RoleKind:         g.role.kind,
+		RoleName:         g.role.name,
+		RoleUID:          g.role.uid,
+	}
+}
+
+// expandRule returns one permission per API group, resource and verb or per non resource URL and verb of the rule.
+func expandRule(g grant, r v1.PolicyRule) []*rbacPermission {
+	results := make([]*rbacPermission, 0)
+	for _, verb := range r.Verbs {
+		for _, group := range r.APIGroups {
+			for _, resource := range r.Resources {
+				item := newRBACPermission(g)
+				item.APIGroup = group
+				item.Resource = resource
+				item.Verb = verb
+				item.ResourceNames = r.ResourceNames
+				results = append(results, item)
+			}
+		}
+		for _, url := range r.NonResourceURLs {
+			item := newRBACPermission(g)
+			item.NonResourceURL = url
+			item.Verb = verb
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+// RBACPermissionColumns returns kubernetes RBAC permission fields as Osquery table columns.
+func RBACPermissionColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&rbacPermission{})
+}
+
+// RBACPermissionsGenerate generates the effective kubernetes RBAC permissions of all subjects as Osquery table data.
+// There is one row per subject, namespace, API group, resource and verb. Empty namespace means cluster-wide.
+func RBACPermissionsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	p, err := loadPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	results := make([]map[string]string, 0)
+	for _, g := range p.grants() {
+		for _, r := range g.role.rules {
+			for _, item := range expandRule(g, r) {
+				bytes, _ := json.Marshal(item)
+				if key := string(bytes); !seen[key] {
+					seen[key] = true
+					results = append(results, k8s.ToMap(item))
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,197 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testClusterRole(name string, labels map[string]string, aggregate map[string]string, rules ...v1.PolicyRule) v1.ClusterRole {
+	cr := v1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID("cr-" + name), Labels: labels},
+		Rules:      rules,
+	}
+	if aggregate != nil {
+		cr.AggregationRule = &v1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: aggregate}},
+		}
+	}
+	return cr
+}
+
+func testPolicy() *policy {
+	roles := []v1.Role{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "ns1", UID: types.UID("r-reader")},
+			Rules:      []v1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}},
+		},
+	}
+	clusterRoles := []v1.ClusterRole{
+		testClusterRole("monitoring", nil, map[string]string{"aggregate-to-monitoring": "true"}),
+		testClusterRole("pods", map[string]string{"aggregate-to-monitoring": "true"}, nil,
+			v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}),
+		testClusterRole("metrics", map[string]string{"aggregate-to-monitoring": "true"}, nil,
+			v1.PolicyRule{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}}),
+		testClusterRole("admin", nil, nil,
+			v1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}),
+	}
+	roleBindings := []v1.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-config", Namespace: "ns1", UID: types.UID("rb-read-config")},
+			RoleRef:    v1.RoleRef{Kind: "Role", Name: "reader"},
+			Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Name: "app"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns-admin", Namespace: "ns2", UID: types.UID("rb-ns-admin")},
+			RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+			Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "alice"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "ns1", UID: types.UID("rb-missing")},
+			RoleRef:    v1.RoleRef{Kind: "Role", Name: "missing"},
+			Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "bob"}},
+		},
+	}
+	clusterRoleBindings := []v1.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring", UID: types.UID("crb-monitoring")},
+			RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "monitoring"},
+			Subjects:   []v1.Subject{{Kind: v1.GroupKind, Name: "system:serviceaccounts:ns2"}},
+		},
+	}
+	sas := []corev1.ServiceAccount{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ns2"}},
+	}
+
+	return newPolicy(roles, clusterRoles, roleBindings, clusterRoleBindings, sas)
+}
+
+func TestAggregateRules(t *testing.T) {
+	p := testPolicy()
+	assert.ElementsMatch(t, []v1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+		{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+	}, p.roles[roleKey("ClusterRole", "", "monitoring")].rules)
+}
+
+func TestPolicyGrants(t *testing.T) {
+	type result struct {
+		subject   v1.Subject
+		viaGroup  string
+		namespace string
+		binding   string
+		role      string
+	}
+
+	results := make([]result, 0)
+	for _, g := range testPolicy().grants() {
+		results = append(results, result{
+			subject:   g.subject,
+			viaGroup:  g.viaGroup,
+			namespace: g.namespace,
+			binding:   g.binding.name,
+			role:      g.role.kind + "/" + g.role.name,
+		})
+	}
+
+	assert.ElementsMatch(t, []result{
+		{
+			subject:   v1.Subject{Kind: v1.GroupKind, Name: "system:serviceaccounts:ns2"},
+			namespace: "",
+			binding:   "monitoring",
+			role:      "ClusterRole/monitoring",
+		},
+		{
+			subject:   v1.Subject{Kind: v1.ServiceAccountKind, Name: "prometheus", Namespace: "ns2"},
+			viaGroup:  "system:serviceaccounts:ns2",
+			namespace: "",
+			binding:   "monitoring",
+			role:      "ClusterRole/monitoring",
+		},
+		{
+			subject:   v1.Subject{Kind: v1.ServiceAccountKind, Name: "app", Namespace: "ns1"},
+			namespace: "ns1",
+			binding:   "read-config",
+			role:      "Role/reader",
+		},
+		{
+			subject:   v1.Subject{Kind: v1.UserKind, Name: "alice"},
+			namespace: "ns2",
+			binding:   "ns-admin",
+			role:      "ClusterRole/admin",
+		},
+	}, results)
+}
+
+func TestExpandRule(t *testing.T) {
+	g := testPolicy().grants()[0]
+	perms := expandRule(g, v1.PolicyRule{
+		APIGroups:     []string{"", "apps"},
+		Resources:     []string{"deployments"},
+		ResourceNames: []string{"web"},
+		Verbs:         []string{"get", "patch"},
+	})
+	assert.Len(t, perms, 4)
+	assert.Equal(t, "apps", perms[1].APIGroup)
+	assert.Equal(t, "get", perms[1].Verb)
+	assert.Equal(t, []string{"web"}, perms[1].ResourceNames)
+
+	perms = expandRule(g, v1.PolicyRule{NonResourceURLs: []string{"/healthz", "/version"}, Verbs: []string{"get"}})
+	assert.Len(t, perms, 2)
+	assert.Equal(t, "/version", perms[1].NonResourceURL)
+	assert.Equal(t, "", perms[1].Resource)
+}
+
+func TestRBACPermissionsGenerate(t *testing.T) {
+	perms, err := RBACPermissionsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Len(t, perms, 13)
+	assert.Contains(t, perms, map[string]string{
+		"api_group":         "metrics.k8s.io",
+		"binding_kind":      "ClusterRoleBinding",
+		"binding_name":      "kubernetes-dashboard",
+		"binding_uid":       "7e3bf161-3a4e-495d-98a8-f71248d0ba36",
+		"cluster_uid":       "a7fd8e77-93de-4742-9037-5db9a01e966a",
+		"resource":          "nodes",
+		"role_kind":         "ClusterRole",
+		"role_name":         "kubernetes-dashboard",
+		"role_uid":          "5afb084d-e4da-4207-844d-d3a2e002ecda",
+		"subject_kind":      "ServiceAccount",
+		"subject_name":      "kubernetes-dashboard",
+		"subject_namespace": "kube-system",
+		"verb":              "watch",
+	})
+	assert.Contains(t, perms, map[string]string{
+		"binding_kind":      "RoleBinding",
+		"binding_name":      "kubernetes-dashboard",
+		"binding_namespace": "kube-system",
+		"binding_uid":       "216b24d7-0611-4cb9-991b-fad53856241d",
+		"cluster_uid":       "a7fd8e77-93de-4742-9037-5db9a01e966a",
+		"namespace":         "kube-system",
+		"resource":          "secrets",
+		"resource_names":    "[\"kubernetes-dashboard-key-holder\",\"kubernetes-dashboard-certs\",\"kubernetes-dashboard-csrf\"]",
+		"role_kind":         "Role",
+		"role_name":         "kubernetes-dashboard",
+		"role_uid":          "74e02baa-2c11-413f-828a-2cbe39011469",
+		"subject_kind":      "ServiceAccount",
+		"subject_name":      "kubernetes-dashboard",
+		"subject_namespace": "kube-system",
+		"verb":              "delete",
+	})
+}
@@ -0,0 +1,247 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RBAC risk severities.
+const (
+	severityCritical = "critical"
+	severityHigh     = "high"
+)
+
+const rbacGroup = "rbac.authorization.k8s.io"
+
+// rbacCheck flags policy rules that allow any of the requests. Cluster-wide checks only apply to ClusterRoleBindings.
+// Named checks also flag rules restricted by resource names, because a single named role, user or group is enough
+// to escalate privileges.
+type rbacCheck struct {
+	id          string
+	severity    string
+	explanation string
+	clusterWide bool
+	named       bool
+	requests    []request
+}
+
+var rbacChecks = []rbacCheck{
+	{
+		id:          "escalate",
+		severity:    severityHigh,
+		explanation: "Escalate verb on roles allows granting permissions the subject does not have",
+		named:       true,
+		requests: []request{
+			{verb: "escalate", apiGroup: rbacGroup, resource: "roles"},
+			{verb: "escalate", apiGroup: rbacGroup, resource: "clusterroles"},
+		},
+	},
+	{
+		id:          "bind",
+		severity:    severityHigh,
+		explanation: "Bind verb on roles allows binding any role to any subject including the subject itself",
+		named:       true,
+		requests: []request{
+			{verb: "bind", apiGroup: rbacGroup, resource: "roles"},
+			{verb: "bind", apiGroup: rbacGroup, resource: "clusterroles"},
+		},
+	},
+	{
+		id:          "impersonate",
+		severity:    severityHigh,
+		explanation: "Impersonate verb allows acting as other users, groups or service accounts",
+		named:       true,
+		requests: []request{
+			{verb: "impersonate", resource: "users"},
+			{verb: "impersonate", resource: "groups"},
+			{verb: "impersonate", resource: "serviceaccounts"},
+		},
+	},
+	{
+		id:          "create-pods",
+		severity:    severityHigh,
+		explanation: "Creating pods allows running as any service account of the namespace and mounting its secrets",
+		requests: []request{
+			{verb: "create", resource: "pods"},
+		},
+	},
+	{
+		id:          "pods-exec",
+		severity:    severityHigh,
+		explanation: "Exec into pods allows running commands in containers with their service account and secrets",
+		requests: []request{
+			{verb: "create", resource: "pods", subresource: "exec"},
+			{verb: "get", resource: "pods", subresource: "exec"},
+		},
+	},
+	{
+		id:          "secrets-cluster-wide",
+		severity:    severityHigh,
+		explanation: "Reading secrets in all namespaces exposes service account tokens and credentials of the whole cluster",
+		clusterWide: true,
+		requests: []request{
+			{verb: "get", resource: "secrets"},
+			{verb: "list", resource: "secrets"},
+			{verb: "watch", resource: "secrets"},
+		},
+	},
+	{
+		id:          "nodes-proxy",
+		severity:    severityHigh,
+		explanation: "Node proxy gives direct access to kubelet API, which allows running commands in any pod of the node",
+		requests: []request{
+			{verb: "get", resource: "nodes", subresource: "proxy"},
+			{verb: "create", resource: "nodes", subresource: "proxy"},
+		},
+	},
+	{
+		id:          "serviceaccount-token",
+		severity:    severityHigh,
+		explanation: "Creating service account tokens allows authenticating as the service accounts",
+		requests: []request{
+			{verb: "create", resource: "serviceaccounts", subresource: "token"},
+		},
+	},
+}
+
+// anonymousSubjects are the user and group names of unauthenticated requests.
+var anonymousSubjects = []string{"system:anonymous", "system:unauthenticated"}
+
+type rbacRisk struct {
+	ClusterUID       types.UID
+	SubjectKind      string
+	SubjectName      string
+	SubjectNamespace string
+	Namespace        string
+	BindingKind      string
+	BindingName      string
+	BindingNamespace string
+	BindingUID       types.UID
+	RoleKind         string
+	RoleName         string
+	RoleUID          types.UID
+	PolicyRule       *v1.PolicyRule
+	RuleID           string
+	Severity         string
+	Explanation      string
+}
+
+// RBACRiskColumns returns kubernetes RBAC risk fields as Osquery table columns.
+func RBACRiskColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&rbacRisk{})
+}
+
+func newRBACRisk(g grant, r *v1.PolicyRule, ruleID, severity, explanation string) *rbacRisk {
+	return &rbacRisk{
+		ClusterUID:       k8s.GetClusterUID(),
+		SubjectKind:      g.subject.Kind,
+		SubjectName:      g.subject.Name,
+		SubjectNamespace: g.subject.Namespace,
+		Namespace:        g.namespace,
+		BindingKind:      g.binding.kind,
+		BindingName:      g.binding.name,
+		BindingNamespace: g.binding.namespace,
+		BindingUID:       g.binding.uid,
+		RoleKind:         g.role.kind,
+		RoleName:         g.role.name,
+		RoleUID:          g.role.uid,
+		PolicyRule:       r,
+		RuleID:           ruleID,
+		Severity:         severity,
+		Explanation:      explanation,
+	}
+}
+
+// evaluateRule returns the risks of the policy rule granted to the subject. Wildcard rules are reported
+// once instead of for every check they would match.
+func evaluateRule(g grant, r v1.PolicyRule) []*rbacRisk {
+	risks := make([]*rbacRisk, 0)
+
+	wildcardVerbs := contains(r.Verbs, v1.VerbAll)
+	wildcardResources := contains(r.Resources, v1.ResourceAll)
+	if wildcardVerbs && wildcardResources && contains(r.APIGroups, v1.APIGroupAll) {
+		return append(risks, newRBACRisk(g, &r, "wildcard", severityCritical,
+			"All verbs on all resources in all API groups. Subject is equivalent to cluster-admin in the scope"))
+	}
+	if wildcardVerbs && len(r.Resources) > 0 {
+		risks = append(risks, newRBACRisk(g, &r, "wildcard-verbs", severityHigh,
+			"All verbs including escalate, bind and impersonate are allowed on the resources"))
+	}
+	if wildcardResources {
+		risks = append(risks, newRBACRisk(g, &r, "wildcard-resources", severityHigh,
+			"Verbs are allowed on all resources including secrets and subresources like pods/exec"))
+	}
+	if wildcardVerbs || wildcardResources {
+		return risks
+	}
+
+	for _, c := range rbacChecks {
+		if c.clusterWide && g.namespace != "" {
+			continue
+		}
+		explanation := c.explanation
+		if c.named && len(r.ResourceNames) > 0 {
+			explanation += ". Restricted to resource names: " + strings.Join(r.ResourceNames, ", ")
+		}
+		for _, req := range c.requests {
+			if c.named && len(r.ResourceNames) > 0 {
+				req.resourceName = r.ResourceNames[0]
+			}
+			if req.allows(r) {
+				risks = append(risks, newRBACRisk(g, &r, c.id, c.severity, explanation))
+				break
+			}
+		}
+	}
+	return risks
+}
+
+// risks returns the risks of all grants. Only subjects bound directly are evaluated, not the service accounts
+// that are members of a bound group.
+func (p *policy) risks() []*rbacRisk {
+	results := make([]*rbacRisk, 0)
+	for _, g := range p.grants() {
+		if g.viaGroup != "" {
+			continue
+		}
+
+		if g.subject.Kind != v1.ServiceAccountKind && contains(anonymousSubjects, g.subject.Name) {
+			results = append(results, newRBACRisk(g, nil, "anonymous-binding", severityCritical,
+				"Role is bound to unauthenticated requests. Anyone that can reach the API server has these permissions"))
+		}
+
+		for _, r := range g.role.rules {
+			results = append(results, evaluateRule(g, r)...)
+		}
+	}
+	return results
+}
+
+// RBACRisksGenerate generates known privilege escalation paths in kubernetes RBAC roles and bindings as Osquery table data.
+func RBACRisksGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	p, err := loadPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, item := range p.risks() {
+		results = append(results, k8s.ToMap(item))
+	}
+
+	return results, nil
+}
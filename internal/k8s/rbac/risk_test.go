@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func getRuleIDs(g grant, r v1.PolicyRule) []string {
+	ids := make([]string, 0)
+	for _, risk := range evaluateRule(g, r) {
+		ids = append(ids, risk.RuleID)
+	}
+	return ids
+}
+
+func TestEvaluateRule(t *testing.T) {
+	b := &binding{kind: "ClusterRoleBinding", name: "b"}
+	ro := &role{kind: "ClusterRole", name: "r"}
+	clusterWide := grant{subject: v1.Subject{Kind: v1.UserKind, Name: "alice"}, binding: b, role: ro}
+	namespaced := grant{subject: v1.Subject{Kind: v1.UserKind, Name: "alice"}, namespace: "ns1", binding: b, role: ro}
+
+	assert.Equal(t, []string{"wildcard"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}))
+	assert.Equal(t, []string{"wildcard-verbs"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"*"}}))
+	assert.Equal(t, []string{"wildcard-resources"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"get"}}))
+	assert.Equal(t, []string{}, getRuleIDs(clusterWide, v1.PolicyRule{NonResourceURLs: []string{"*"}, Verbs: []string{"*"}}))
+
+	assert.Equal(t, []string{"escalate", "bind"}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{rbacGroup}, Resources: []string{"roles"}, Verbs: []string{"bind", "escalate"}}))
+	assert.Equal(t, []string{"impersonate"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"serviceaccounts"}, Verbs: []string{"impersonate"}}))
+	assert.Equal(t, []string{"create-pods"}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "get"}}))
+	assert.Equal(t, []string{"pods-exec"}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}}))
+	assert.Equal(t, []string{"secrets-cluster-wide"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}}))
+	assert.Equal(t, []string{}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}}))
+	assert.Equal(t, []string{}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"s1"}, Verbs: []string{"get"}}))
+	assert.Equal(t, []string{"impersonate"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"users"}, ResourceNames: []string{"system:admin"}, Verbs: []string{"impersonate"}}))
+	assert.Equal(t, []string{"bind"}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{rbacGroup}, Resources: []string{"clusterroles"}, ResourceNames: []string{"cluster-admin"}, Verbs: []string{"bind"}}))
+	assert.Equal(t, []string{}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, ResourceNames: []string{"web-1"}, Verbs: []string{"create"}}))
+	assert.Equal(t, []string{"nodes-proxy"}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes/proxy"}, Verbs: []string{"get"}}))
+	assert.Equal(t, []string{"serviceaccount-token"}, getRuleIDs(namespaced, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"serviceaccounts/token"}, Verbs: []string{"create"}}))
+	assert.Equal(t, []string{}, getRuleIDs(clusterWide, v1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list"}}))
+}
+
+func TestEvaluateRuleResourceNames(t *testing.T) {
+	g := grant{subject: v1.Subject{Kind: v1.UserKind, Name: "alice"}, binding: &binding{kind: "ClusterRoleBinding", name: "b"}, role: &role{kind: "ClusterRole", name: "r"}}
+
+	risks := evaluateRule(g, v1.PolicyRule{APIGroups: []string{rbacGroup}, Resources: []string{"roles", "clusterroles"}, ResourceNames: []string{"admin", "cluster-admin"}, Verbs: []string{"escalate"}})
+	assert.Len(t, risks, 1)
+	assert.Equal(t, "escalate", risks[0].RuleID)
+	assert.Equal(t, "Escalate verb on roles allows granting permissions the subject does not have. Restricted to resource names: admin, cluster-admin", risks[0].Explanation)
+
+	risks = evaluateRule(g, v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"groups"}, Verbs: []string{"impersonate"}})
+	assert.Len(t, risks, 1)
+	assert.Equal(t, "Impersonate verb allows acting as other users, groups or service accounts", risks[0].Explanation)
+}
+
+func TestPolicyRisks(t *testing.T) {
+	p := newPolicy(nil, []v1.ClusterRole{
+		testClusterRole("discovery", nil, nil, v1.PolicyRule{NonResourceURLs: []string{"/api"}, Verbs: []string{"get"}}),
+	}, nil, []v1.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "anonymous", UID: types.UID("crb-anonymous")},
+			RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "discovery"},
+			Subjects: []v1.Subject{
+				{Kind: v1.GroupKind, Name: "system:unauthenticated"},
+				{Kind: v1.UserKind, Name: "system:anonymous"},
+				{Kind: v1.UserKind, Name: "alice"},
+			},
+		},
+	}, nil)
+
+	risks := p.risks()
+	assert.Len(t, risks, 2)
+	for _, r := range risks {
+		assert.Equal(t, "anonymous-binding", r.RuleID)
+		assert.Equal(t, severityCritical, r.Severity)
+		assert.Equal(t, types.UID("crb-anonymous"), r.BindingUID)
+		assert.Equal(t, types.UID("cr-discovery"), r.RoleUID)
+		assert.Nil(t, r.PolicyRule)
+	}
+	assert.Equal(t, "system:unauthenticated", risks[0].SubjectName)
+	assert.Equal(t, "system:anonymous", risks[1].SubjectName)
+
+	risks = testPolicy().risks()
+	assert.Len(t, risks, 1)
+	assert.Equal(t, "wildcard", risks[0].RuleID)
+	assert.Equal(t, "ns2", risks[0].Namespace)
+}
+
+func TestRBACRisksGenerate(t *testing.T) {
+	risks, err := RBACRisksGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{}, risks)
+}
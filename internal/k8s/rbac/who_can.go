@@ -0,0 +1,202 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errMissingVerbResource is returned when the who can table is queried without verb or resource constraints.
+var errMissingVerbResource = errors.New("verb and resource constraints are required")
+
+// request describes an action on a resource similar to RBAC authorizer attributes. Request with anyAPIGroup
+// matches the resource in all API groups.
+type request struct {
+	verb         string
+	apiGroup     string
+	anyAPIGroup  bool
+	resource     string
+	subresource  string
+	resourceName string
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func verbMatches(r v1.PolicyRule, verb string) bool {
+	return contains(r.Verbs, v1.VerbAll) || contains(r.Verbs, verb)
+}
+
+func apiGroupMatches(r v1.PolicyRule, group string) bool {
+	return contains(r.APIGroups, v1.APIGroupAll) || contains(r.APIGroups, group)
+}
+
+// resourceMatches returns true if the rule matches the resource and subresource. Rule resource */subresource matches
+// the subresource of all resources.
+func resourceMatches(r v1.PolicyRule, resource, subresource string) bool {
+	combined := resource
+	if subresource != "" {
+		combined = resource + "/" + subresource
+	}
+
+	for _, rr := range r.Resources {
+		if rr == v1.ResourceAll || rr == combined {
+			return true
+		}
+		if subresource != "" && rr == "*/"+subresource {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceNameMatches returns true if the rule is not restricted to resource names or the name is one of them.
+func resourceNameMatches(r v1.PolicyRule, name string) bool {
+	return len(r.ResourceNames) == 0 || contains(r.ResourceNames, name)
+}
+
+// allows returns true if the rule allows the request.
+func (req request) allows(r v1.PolicyRule) bool {
+	return verbMatches(r, req.verb) &&
+		(req.anyAPIGroup || apiGroupMatches(r, req.apiGroup)) &&
+		resourceMatches(r, req.resource, req.subresource) &&
+		resourceNameMatches(r, req.resourceName)
+}
+
+// RuleAllows returns true if the policy rule allows the verb on the resource. Resource can include the subresource,
+// for example pods/exec.
+func RuleAllows(r v1.PolicyRule, verb, apiGroup, resource string) bool {
+	req := request{verb: verb, apiGroup: apiGroup, resource: resource}
+	if parts := strings.SplitN(resource, "/", 2); len(parts) == 2 {
+		req.resource = parts[0]
+		req.subresource = parts[1]
+	}
+	return req.allows(r)
+}
+
+type whoCan struct {
+	ClusterUID       types.UID
+	Verb             string
+	APIGroup         string
+	Resource         string
+	Subresource      string
+	ResourceName     string
+	Namespace        string
+	SubjectKind      string
+	SubjectName      string
+	SubjectNamespace string
+	ViaGroup         string
+	BindingKind      string
+	BindingName      string
+	BindingNamespace string
+	BindingUID       types.UID
+	RoleKind         string
+	RoleName         string
+	RoleUID          types.UID
+}
+
+// WhoCanColumns returns kubernetes who can fields as Osquery table columns.
+func WhoCanColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&whoCan{})
+}
+
+// WhoCanGenerate generates the kubernetes subjects allowed to perform an action as Osquery table data.
+// verb and resource constraints are required. Resource can include the subresource, for example pods/exec.
+// Without namespace constraint, namespace column is the namespace of the binding or empty if it is cluster-wide.
+// Without api_group constraint, the resource is matched in all API groups and api_group column is *.
+func WhoCanGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	verb, ok := k8s.GetConstraint(queryContext, "verb")
+	if !ok {
+		return nil, errMissingVerbResource
+	}
+	resource, ok := k8s.GetConstraint(queryContext, "resource")
+	if !ok {
+		return nil, errMissingVerbResource
+	}
+	apiGroup, hasAPIGroup := k8s.GetConstraint(queryContext, "api_group")
+	subresource, _ := k8s.GetConstraint(queryContext, "subresource")
+	resourceName, _ := k8s.GetConstraint(queryContext, "resource_name")
+	namespace, hasNamespace := k8s.GetConstraint(queryContext, "namespace")
+
+	req := request{
+		verb:         verb,
+		apiGroup:     apiGroup,
+		anyAPIGroup:  !hasAPIGroup,
+		resource:     resource,
+		subresource:  subresource,
+		resourceName: resourceName,
+	}
+	if !hasAPIGroup {
+		apiGroup = v1.APIGroupAll
+	}
+	if parts := strings.SplitN(resource, "/", 2); len(parts) == 2 && subresource == "" {
+		req.resource = parts[0]
+		req.subresource = parts[1]
+	}
+
+	p, err := loadPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, g := range p.grants() {
+		if hasNamespace && g.namespace != "" && g.namespace != namespace {
+			continue
+		}
+
+		for _, r := range g.role.rules {
+			if !req.allows(r) {
+				continue
+			}
+
+			item := &whoCan{
+				ClusterUID:       k8s.GetClusterUID(),
+				Verb:             verb,
+				APIGroup:         apiGroup,
+				Resource:         resource,
+				Subresource:      subresource,
+				ResourceName:     resourceName,
+				Namespace:        g.namespace,
+				SubjectKind:      g.subject.Kind,
+				SubjectName:      g.subject.Name,
+				SubjectNamespace: g.subject.Namespace,
+				ViaGroup:         g.viaGroup,
+				BindingKind:      g.binding.kind,
+				BindingName:      g.binding.name,
+				BindingNamespace: g.binding.namespace,
+				BindingUID:       g.binding.uid,
+				RoleKind:         g.role.kind,
+				RoleName:         g.role.name,
+				RoleUID:          g.role.uid,
+			}
+			if hasNamespace {
+				item.Namespace = namespace
+			}
+			results = append(results, k8s.ToMap(item))
+			break
+		}
+	}
+
+	return results, nil
+}
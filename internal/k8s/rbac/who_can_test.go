@@ -0,0 +1,160 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+)
+
+func TestRequestAllows(t *testing.T) {
+	exec := request{verb: "create", resource: "pods", subresource: "exec"}
+	assert.True(t, exec.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}}))
+	assert.True(t, exec.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"*/exec"}, Verbs: []string{"create"}}))
+	assert.True(t, exec.allows(v1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}))
+	assert.False(t, exec.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create"}}))
+	assert.False(t, exec.allows(v1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}}))
+	assert.False(t, exec.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"get"}}))
+
+	deployments := request{verb: "get", anyAPIGroup: true, resource: "deployments"}
+	assert.True(t, deployments.allows(v1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}}))
+	assert.True(t, deployments.allows(v1.PolicyRule{APIGroups: []string{"extensions"}, Resources: []string{"deployments"}, Verbs: []string{"get"}}))
+	assert.False(t, deployments.allows(v1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}, Verbs: []string{"get"}}))
+
+	pods := request{verb: "get", resource: "pods"}
+	assert.False(t, pods.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"*/exec"}, Verbs: []string{"get"}}))
+
+	secret := request{verb: "get", resource: "secrets", resourceName: "token"}
+	assert.True(t, secret.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}))
+	assert.True(t, secret.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"token"}, Verbs: []string{"get"}}))
+	assert.False(t, secret.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"other"}, Verbs: []string{"get"}}))
+
+	secrets := request{verb: "list", resource: "secrets"}
+	assert.False(t, secrets.allows(v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"token"}, Verbs: []string{"list"}}))
+}
+
+func TestRuleAllows(t *testing.T) {
+	r := v1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods", "pods/exec"}, Verbs: []string{"create"}}
+	assert.True(t, RuleAllows(r, "create", "", "pods"))
+	assert.True(t, RuleAllows(r, "create", "", "pods/exec"))
+	assert.False(t, RuleAllows(r, "create", "", "pods/log"))
+	assert.False(t, RuleAllows(r, "get", "", "pods"))
+}
+
+func getConstraints(constraints map[string]string) table.QueryContext {
+	queryContext := table.QueryContext{Constraints: map[string]table.ConstraintList{}}
+	for k, v := range constraints {
+		queryContext.Constraints[k] = table.ConstraintList{
+			Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: v}},
+		}
+	}
+	return queryContext
+}
+
+func TestWhoCanGenerate(t *testing.T) {
+	_, err := WhoCanGenerate(context.TODO(), getConstraints(map[string]string{"verb": "get"}))
+	assert.Equal(t, errMissingVerbResource, err)
+
+	wcs, err := WhoCanGenerate(context.TODO(), getConstraints(map[string]string{
+		"verb":      "get",
+		"resource":  "services/proxy",
+		"namespace": "kube-system",
+	}))
+	assert.Nil(t, err)
+	assert.Len(t, wcs, 0)
+
+	wcs, err = WhoCanGenerate(context.TODO(), getConstraints(map[string]string{
+		"verb":          "get",
+		"resource":      "services/proxy",
+		"resource_name": "heapster",
+		"namespace":     "kube-system",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"api_group":         "*",
+			"binding_kind":      "RoleBinding",
+			"binding_name":      "kubernetes-dashboard",
+			"binding_namespace": "kube-system",
+			"binding_uid":       "216b24d7-0611-4cb9-991b-fad53856241d",
+			"cluster_uid":       "a7fd8e77-93de-4742-9037-5db9a01e966a",
+			"namespace":         "kube-system",
+			"resource":          "services/proxy",
+			"resource_name":     "heapster",
+			"role_kind":         "Role",
+			"role_name":         "kubernetes-dashboard",
+			"role_uid":          "74e02baa-2c11-413f-828a-2cbe39011469",
+			"subject_kind":      "ServiceAccount",
+			"subject_name":      "kubernetes-dashboard",
+			"subject_namespace": "kube-system",
+			"verb":              "get",
+		},
+	}, wcs)
+
+	// Without api_group constraint, the resource is matched in all API groups
+	wcs, err = WhoCanGenerate(context.TODO(), getConstraints(map[string]string{
+		"verb":     "list",
+		"resource": "nodes",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"api_group":         "*",
+			"binding_kind":      "ClusterRoleBinding",
+			"binding_name":      "kubernetes-dashboard",
+			"binding_uid":       "7e3bf161-3a4e-495d-98a8-f71248d0ba36",
+			"cluster_uid":       "a7fd8e77-93de-4742-9037-5db9a01e966a",
+			"resource":          "nodes",
+			"role_kind":         "ClusterRole",
+			"role_name":         "kubernetes-dashboard",
+			"role_uid":          "5afb084d-e4da-4207-844d-d3a2e002ecda",
+			"subject_kind":      "ServiceAccount",
+			"subject_name":      "kubernetes-dashboard",
+			"subject_namespace": "kube-system",
+			"verb":              "list",
+		},
+	}, wcs)
+
+	wcs, err = WhoCanGenerate(context.TODO(), getConstraints(map[string]string{
+		"verb":      "list",
+		"resource":  "nodes",
+		"api_group": "",
+	}))
+	assert.Nil(t, err)
+	assert.Len(t, wcs, 0)
+
+	wcs, err = WhoCanGenerate(context.TODO(), getConstraints(map[string]string{
+		"verb":      "list",
+		"resource":  "nodes",
+		"api_group": "metrics.k8s.io",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"api_group":         "metrics.k8s.io",
+			"binding_kind":      "ClusterRoleBinding",
+			"binding_name":      "kubernetes-dashboard",
+			"binding_uid":       "7e3bf161-3a4e-495d-98a8-f71248d0ba36",
+			"cluster_uid":       "a7fd8e77-93de-4742-9037-5db9a01e966a",
+			"resource":          "nodes",
+			"role_kind":         "ClusterRole",
+			"role_name":         "kubernetes-dashboard",
+			"role_uid":          "5afb084d-e4da-4207-844d-d3a2e002ecda",
+			"subject_kind":      "ServiceAccount",
+			"subject_name":      "kubernetes-dashboard",
+			"subject_namespace": "kube-system",
+			"verb":              "list",
+		},
+	}, wcs)
+}
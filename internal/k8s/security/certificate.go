@@ -0,0 +1,223 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// now is overridden in tests to get stable days until expiry.
+var now = time.Now
+
+type certificate struct {
+	ClusterUID      types.UID
+	SourceKind      string
+	SourceUID       types.UID
+	SourceNamespace string
+	SourceName      string
+	SourceField     string
+	k8s.CertificateFields
+	DaysUntilExpiry int64
+}
+
+// CertificateColumns returns X.509 certificate fields as Osquery table columns.
+func CertificateColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&certificate{})
+}
+
+// getDaysUntilExpiry returns the whole days until the time. It is negative once the time has passed.
+func getDaysUntilExpiry(notAfter time.Time) int64 {
+	return int64(math.Floor(notAfter.Sub(now()).Hours() / 24))
+}
+
+func appendCertificates(results []map[string]string, kind string, obj metav1.ObjectMeta, field string, data []byte) []map[string]string {
+	for _, c := range k8s.ParseCertificates(data) {
+		item := &certificate{
+			ClusterUID:        k8s.GetClusterUID(),
+			SourceKind:        kind,
+			SourceUID:         obj.UID,
+			SourceNamespace:   obj.Namespace,
+			SourceName:        obj.Name,
+			SourceField:       field,
+			CertificateFields: k8s.GetCertificateFields(c),
+			DaysUntilExpiry:   getDaysUntilExpiry(c.NotAfter),
+		}
+		results = append(results, k8s.ToMap(item))
+	}
+	return results
+}
+
+// appendDataCertificates appends the certificates in all data keys of the object. Keys are sorted so that
+// the rows are in the same order in every query.
+func appendDataCertificates(results []map[string]string, kind string, meta metav1.ObjectMeta, data map[string][]byte) []map[string]string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		results = appendCertificates(results, kind, meta, k, data[k])
+	}
+	return results
+}
+
+func secretCertificates(ctx context.Context, results []map[string]string) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+
+	for {
+		secrets, err := k8s.GetClient(ctx).CoreV1().Secrets(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range secrets.Items {
+			if s.Type != v1.SecretTypeTLS {
+				continue
+			}
+			results = appendDataCertificates(results, "Secret", s.ObjectMeta, s.Data)
+		}
+
+		if secrets.Continue == "" {
+			break
+		}
+		options.Continue = secrets.Continue
+	}
+
+	return results, nil
+}
+
+func configMapCertificates(ctx context.Context, results []map[string]string) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+
+	for {
+		cms, err := k8s.GetClient(ctx).CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cm := range cms.Items {
+			data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+			for k, v := range cm.Data {
+				data[k] = []byte(v)
+			}
+			for k, v := range cm.BinaryData {
+				data[k] = v
+			}
+			results = appendDataCertificates(results, "ConfigMap", cm.ObjectMeta, data)
+		}
+
+		if cms.Continue == "" {
+			break
+		}
+		options.Continue = cms.Continue
+	}
+
+	return results, nil
+}
+
+func webhookCertificates(ctx context.Context, results []map[string]string) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+
+	for {
+		mwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mwc := range mwcs.Items {
+			for _, w := range mwc.Webhooks {
+				field := fmt.Sprintf("webhooks[%s].clientConfig.caBundle", w.Name)
+				results = appendCertificates(results, "MutatingWebhookConfiguration", mwc.ObjectMeta, field, w.ClientConfig.CABundle)
+			}
+		}
+
+		if mwcs.Continue == "" {
+			break
+		}
+		options.Continue = mwcs.Continue
+	}
+
+	options = metav1.ListOptions{}
+	for {
+		vwcs, err := k8s.GetClient(ctx).AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vwc := range vwcs.Items {
+			for _, w := range vwc.Webhooks {
+				field := fmt.Sprintf("webhooks[%s].clientConfig.caBundle", w.Name)
+				results = appendCertificates(results, "ValidatingWebhookConfiguration", vwc.ObjectMeta, field, w.ClientConfig.CABundle)
+			}
+		}
+
+		if vwcs.Continue == "" {
+			break
+		}
+		options.Continue = vwcs.Continue
+	}
+
+	return results, nil
+}
+
+func apiServiceCertificates(ctx context.Context, results []map[string]string) ([]map[string]string, error) {
+	options := metav1.ListOptions{}
+
+	for {
+		ass, err := k8s.GetAggregatorClient(ctx).ApiregistrationV1().APIServices().List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, as := range ass.Items {
+			results = appendCertificates(results, "APIService", as.ObjectMeta, "spec.caBundle", as.Spec.CABundle)
+		}
+
+		if ass.Continue == "" {
+			break
+		}
+		options.Continue = ass.Continue
+	}
+
+	return results, nil
+}
+
+// CertificatesGenerate generates X.509 certificates found in TLS secrets, config maps,
+// webhook configuration CA bundles and API service CA bundles as Osquery table data.
+func CertificatesGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	var err error
+	results := make([]map[string]string, 0)
+
+	sources := []func(context.Context, []map[string]string) ([]map[string]string, error){
+		secretCertificates,
+		configMapCertificates,
+		webhookCertificates,
+		apiServiceCertificates,
+	}
+	for _, source := range sources {
+		results, err = source(ctx, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
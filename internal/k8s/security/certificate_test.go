@@ -0,0 +1,147 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCertificatesGenerate(t *testing.T) {
+	certs, err := CertificatesGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "tls.crt",
+			"source_kind":                      "Secret",
+			"source_name":                      "tls1",
+			"source_namespace":                 "default",
+			"source_uid":                       "s123",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "ca.crt",
+			"source_kind":                      "ConfigMap",
+			"source_name":                      "kube-root-ca.crt",
+			"source_namespace":                 "default",
+			"source_uid":                       "c123",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "ca.der",
+			"source_kind":                      "ConfigMap",
+			"source_name":                      "kube-root-ca.crt",
+			"source_namespace":                 "default",
+			"source_uid":                       "c123",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "webhooks[mw1].clientConfig.caBundle",
+			"source_kind":                      "MutatingWebhookConfiguration",
+			"source_name":                      "mwc1",
+			"source_uid":                       "m123",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "webhooks[vw1].clientConfig.caBundle",
+			"source_kind":                      "ValidatingWebhookConfiguration",
+			"source_name":                      "vwc1",
+			"source_uid":                       "v123",
+		},
+		{
+			"certificate_issuer":               "O=cluster.local",
+			"certificate_not_after":            "1926551190",
+			"certificate_not_before":           "1611191190",
+			"certificate_public_key_algorithm": "RSA",
+			"certificate_public_key_size":      "2048",
+			"certificate_serial_number":        "42230BEFFA59D961CB615434687CD92C",
+			"certificate_subject":              "O=cluster.local",
+			"certificate_subject_alt_names":    "[]",
+			"cluster_uid":                      "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"days_until_expiry":                "3640",
+			"source_field":                     "spec.caBundle",
+			"source_kind":                      "APIService",
+			"source_name":                      "v1beta1.metrics.k8s.io",
+			"source_uid":                       "a123",
+		},
+	}, certs)
+}
+
+func TestGetDaysUntilExpiry(t *testing.T) {
+	assert.Equal(t, int64(1), getDaysUntilExpiry(now().Add(36*time.Hour)))
+	assert.Equal(t, int64(0), getDaysUntilExpiry(now().Add(12*time.Hour)))
+	assert.Equal(t, int64(-1), getDaysUntilExpiry(now().Add(-12*time.Hour)))
+	assert.Equal(t, int64(-2), getDaysUntilExpiry(now().Add(-36*time.Hour)))
+}
+
+func TestAppendDataCertificates(t *testing.T) {
+	ca := loadTestData("ca_test.pem")
+	data := map[string][]byte{"z.crt": ca, "a.crt": ca, "other": []byte("value"), "m.crt": ca}
+
+	for i := 0; i < 10; i++ {
+		certs := appendDataCertificates(nil, "Secret", metav1.ObjectMeta{Name: "s1"}, data)
+		fields := make([]string, 0, len(certs))
+		for _, c := range certs {
+			fields = append(fields, c["source_field"])
+		}
+		assert.Equal(t, []string{"a.crt", "m.crt", "z.crt"}, fields)
+	}
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package security
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregatorfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+)
+
+func loadTestData(name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func init() {
+	yes, no := true, false
+	now = func() time.Time {
+		return time.Date(2021, time.January, 31, 1, 6, 30, 0, time.UTC)
+	}
+
+	ca := loadTestData("ca_test.pem")
+	tls := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls1", Namespace: "default", UID: types.UID("s123")},
+		Type:       v1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": ca, "tls.key": []byte("key")},
+	}
+	opaque := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "opaque1", Namespace: "default", UID: types.UID("s456")},
+		Type:       v1.SecretTypeOpaque,
+		Data:       map[string][]byte{"ca.crt": ca},
+	}
+	der, _ := pem.Decode(ca)
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "default", UID: types.UID("c123")},
+		Data:       map[string]string{"ca.crt": string(ca), "other": "value"},
+		BinaryData: map[string][]byte{"ca.der": der.Bytes},
+	}
+	mwc := &admissionv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mwc1", UID: types.UID("m123")},
+		Webhooks: []admissionv1.MutatingWebhook{
+			{Name: "mw1", ClientConfig: admissionv1.WebhookClientConfig{CABundle: ca}},
+			{Name: "mw2"},
+		},
+	}
+	vwc := &admissionv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "vwc1", UID: types.UID("v123")},
+		Webhooks: []admissionv1.ValidatingWebhook{
+			{Name: "vw1", ClientConfig: admissionv1.WebhookClientConfig{CABundle: ca}},
+		},
+	}
+	as := &apiregistrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1beta1.metrics.k8s.io", UID: types.UID("a123")},
+		Spec:       apiregistrationv1.APIServiceSpec{CABundle: ca},
+	}
+
+	restricted := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted1", Namespace: "default", UID: types.UID("p123")},
+		Spec: v1.PodSpec{
+			SecurityContext: &v1.PodSecurityContext{
+				RunAsNonRoot:   &yes,
+				SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []v1.Container{{
+				Name: "app",
+				SecurityContext: &v1.SecurityContext{
+					AllowPrivilegeEscalation: &no,
+					Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+	privileged := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "privileged1", Namespace: "default", UID: types.UID("d123")},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					HostNetwork: true,
+					SecurityContext: &v1.PodSecurityContext{
+						RunAsNonRoot:   &yes,
+						SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+					},
+					Containers: []v1.Container{{
+						Name: "app",
+						SecurityContext: &v1.SecurityContext{
+							Privileged:               &yes,
+							AllowPrivilegeEscalation: &no,
+							Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	k8s.SetClient(fake.NewSimpleClientset(tls, opaque, cm, mwc, vwc, restricted, privileged), types.UID("b7fd8e77-93de-4742-9037-5db9a01e966a"))
+	k8s.SetAggregatorClient(aggregatorfake.NewSimpleClientset(as))
+}
@@ -0,0 +1,262 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Pod Security Standards levels.
+const (
+	levelBaseline   = "baseline"
+	levelRestricted = "restricted"
+)
+
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+var (
+	baselineCapabilities = []v1.Capability{
+		"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+		"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+	}
+	baselineSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t"}
+	baselineSysctls      = []string{
+		"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start",
+		"net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range",
+	}
+	restrictedVolumeTypes = []string{
+		"config_map", "csi", "downward_api", "empty_dir", "ephemeral", "persistent_volume_claim", "projected", "secret",
+	}
+)
+
+type podSecurityViolation struct {
+	ClusterUID   types.UID
+	Namespace    string
+	WorkloadKind string
+	WorkloadName string
+	WorkloadUID  types.UID
+	Container    string
+	CheckID      string
+	Level        string
+	Detail       string
+}
+
+// PodSecurityViolationColumns returns Pod Security Standards violation fields as Osquery table columns.
+func PodSecurityViolationColumns() []table.ColumnDefinition {
+	return k8s.GetSchema(&podSecurityViolation{})
+}
+
+// podContainer is a container of any type with its security context flattened.
+type podContainer struct {
+	name string
+	k8s.CommonContainerFields
+}
+
+func getPodContainers(spec v1.PodSpec) []podContainer {
+	results := make([]podContainer, 0, len(spec.InitContainers)+len(spec.Containers)+len(spec.EphemeralContainers))
+	for _, c := range spec.InitContainers {
+		results = append(results, podContainer{name: c.Name, CommonContainerFields: k8s.GetCommonContainerFields(c)})
+	}
+	for _, c := range spec.Containers {
+		results = append(results, podContainer{name: c.Name, CommonContainerFields: k8s.GetCommonContainerFields(c)})
+	}
+	for _, c := range spec.EphemeralContainers {
+		results = append(results, podContainer{name: c.Name, CommonContainerFields: k8s.GetCommonEphemeralContainerFields(c)})
+	}
+	return results
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCapability(values []v1.Capability, value v1.Capability) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// podSecurityChecker collects violations of a single workload.
+type podSecurityChecker struct {
+	w          workload.Workload
+	violations []*podSecurityViolation
+}
+
+func (c *podSecurityChecker) add(container, checkID, level, detail string) {
+	c.violations = append(c.violations, &podSecurityViolation{
+		ClusterUID:   k8s.GetClusterUID(),
+		Namespace:    c.w.ObjectMeta.Namespace,
+		WorkloadKind: c.w.Kind,
+		WorkloadName: c.w.ObjectMeta.Name,
+		WorkloadUID:  c.w.ObjectMeta.UID,
+		Container:    container,
+		CheckID:      checkID,
+		Level:        level,
+		Detail:       detail,
+	})
+}
+
+func (c *podSecurityChecker) checkSELinux(container string, sc k8s.SELinuxOptionsFields) {
+	if !containsString(baselineSELinuxTypes, sc.SELinuxOptionsType) {
+		c.add(container, "selinux", levelBaseline, fmt.Sprintf("seLinuxOptions.type=%s", sc.SELinuxOptionsType))
+	}
+	if sc.SELinuxOptionsUser != "" || sc.SELinuxOptionsRole != "" {
+		c.add(container, "selinux", levelBaseline, "seLinuxOptions.user and seLinuxOptions.role must not be set")
+	}
+}
+
+func (c *podSecurityChecker) checkPod(pod k8s.CommonPodFields) {
+	if pod.HostNetwork {
+		c.add("", "host-namespaces", levelBaseline, "hostNetwork=true")
+	}
+	if pod.HostPID {
+		c.add("", "host-namespaces", levelBaseline, "hostPID=true")
+	}
+	if pod.HostIPC {
+		c.add("", "host-namespaces", levelBaseline, "hostIPC=true")
+	}
+
+	c.checkSELinux("", pod.SELinuxOptionsFields)
+	if pod.SeccompProfileType == v1.SeccompProfileTypeUnconfined {
+		c.add("", "seccomp", levelBaseline, "seccompProfile.type=Unconfined")
+	}
+	for _, s := range pod.Sysctls {
+		if !containsString(baselineSysctls, s.Name) {
+			c.add("", "sysctls", levelBaseline, fmt.Sprintf("sysctl %s is not allowed", s.Name))
+		}
+	}
+
+	if pod.RunAsUser != nil && *pod.RunAsUser == 0 {
+		c.add("", "run-as-user", levelRestricted, "runAsUser=0")
+	}
+}
+
+func (c *podSecurityChecker) checkVolumes(volumes []v1.Volume) {
+	for _, v := range volumes {
+		fields := k8s.GetCommonVolumeFields(v)
+		if fields.VolumeType == "host_path" {
+			c.add("", "host-path-volumes", levelBaseline, fmt.Sprintf("volume %s uses host path %s", v.Name, fields.HostPathPath))
+		} else if !containsString(restrictedVolumeTypes, fields.VolumeType) {
+			c.add("", "volume-types", levelRestricted, fmt.Sprintf("volume %s has type %s", v.Name, fields.VolumeType))
+		}
+	}
+}
+
+func (c *podSecurityChecker) checkContainer(pod k8s.CommonPodFields, container podContainer) {
+	name := container.name
+	sc := container.SecurityContextFields
+
+	if sc.Privileged != nil && *sc.Privileged {
+		c.add(name, "privileged", levelBaseline, "privileged=true")
+	}
+
+	for _, capability := range sc.CapabilitiesAdd {
+		if !containsCapability(baselineCapabilities, capability) {
+			c.add(name, "capabilities", levelBaseline, fmt.Sprintf("capability %s is added", capability))
+		} else if capability != "NET_BIND_SERVICE" {
+			c.add(name, "capabilities", levelRestricted, fmt.Sprintf("capability %s is added", capability))
+		}
+	}
+	if !containsCapability(sc.CapabilitiesDrop, "ALL") {
+		c.add(name, "capabilities", levelRestricted, "capabilities must drop ALL")
+	}
+
+	for _, p := range container.Ports {
+		if p.HostPort != 0 {
+			c.add(name, "host-ports", levelBaseline, fmt.Sprintf("hostPort=%d", p.HostPort))
+		}
+	}
+
+	if profile, ok := c.w.Template.Annotations[appArmorAnnotationPrefix+name]; ok {
+		if profile != "runtime/default" && !strings.HasPrefix(profile, "localhost/") {
+			c.add(name, "apparmor", levelBaseline, fmt.Sprintf("AppArmor profile %s", profile))
+		}
+	}
+
+	c.checkSELinux(name, sc.SELinuxOptionsFields)
+
+	if sc.ProcMount != nil && *sc.ProcMount != v1.DefaultProcMount {
+		c.add(name, "proc-mount", levelBaseline, fmt.Sprintf("procMount=%s", *sc.ProcMount))
+	}
+
+	seccomp := sc.SeccompProfileType
+	if seccomp == "" {
+		seccomp = pod.SeccompProfileType
+	}
+	if sc.SeccompProfileType == v1.SeccompProfileTypeUnconfined {
+		c.add(name, "seccomp", levelBaseline, "seccompProfile.type=Unconfined")
+	}
+	if seccomp != v1.SeccompProfileTypeRuntimeDefault && seccomp != v1.SeccompProfileTypeLocalhost {
+		c.add(name, "seccomp", levelRestricted, "seccompProfile.type must be RuntimeDefault or Localhost")
+	}
+
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		c.add(name, "privilege-escalation", levelRestricted, "allowPrivilegeEscalation must be false")
+	}
+
+	runAsNonRoot := pod.RunAsNonRoot
+	if sc.RunAsNonRoot != nil {
+		runAsNonRoot = sc.RunAsNonRoot
+	}
+	if runAsNonRoot == nil || !*runAsNonRoot {
+		c.add(name, "run-as-non-root", levelRestricted, "runAsNonRoot must be true")
+	}
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		c.add(name, "run-as-user", levelRestricted, "runAsUser=0")
+	}
+}
+
+// evaluatePodSecurity returns the Pod Security Standards violations of the workload. Level is the profile
+// that the check belongs to. Baseline violations also violate restricted profile.
+func evaluatePodSecurity(w workload.Workload) []*podSecurityViolation {
+	c := &podSecurityChecker{w: w, violations: make([]*podSecurityViolation, 0)}
+
+	pod := k8s.GetCommonPodFields(w.Spec)
+	c.checkPod(pod)
+	c.checkVolumes(w.Spec.Volumes)
+	for _, container := range getPodContainers(w.Spec) {
+		c.checkContainer(pod, container)
+	}
+
+	return c.violations
+}
+
+// PodSecurityViolationsGenerate generates Pod Security Standards violations of pods and pod templates as Osquery table data.
+func PodSecurityViolationsGenerate(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	ws, err := workload.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, 0)
+	for _, w := range ws {
+		for _, v := range evaluatePodSecurity(w) {
+			results = append(results, k8s.ToMap(v))
+		}
+	}
+
+	return results, nil
+}
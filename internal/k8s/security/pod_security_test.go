@@ -0,0 +1,158 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package security
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s/workload"
+	"github.com/kolide/osquery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type check struct {
+	container string
+	id        string
+	level     string
+}
+
+func getChecks(vs []*podSecurityViolation) []check {
+	results := make([]check, 0, len(vs))
+	for _, v := range vs {
+		results = append(results, check{container: v.Container, id: v.CheckID, level: v.Level})
+	}
+	return results
+}
+
+func TestEvaluatePodSecurity(t *testing.T) {
+	yes, no := true, false
+	root := int64(0)
+	unmasked := v1.UnmaskedProcMount
+
+	w := workload.Workload{
+		Kind:       workload.KindPod,
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "n1"},
+		Template: metav1.ObjectMeta{Annotations: map[string]string{
+			appArmorAnnotationPrefix + "c1": "unconfined",
+			appArmorAnnotationPrefix + "c2": "localhost/custom",
+		}},
+		Spec: v1.PodSpec{
+			HostPID: true,
+			SecurityContext: &v1.PodSecurityContext{
+				RunAsUser: &root,
+				Sysctls:   []v1.Sysctl{{Name: "kernel.shm_rmid_forced"}, {Name: "net.core.somaxconn"}},
+			},
+			Volumes: []v1.Volume{
+				{Name: "host", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/"}}},
+				{Name: "nfs", VolumeSource: v1.VolumeSource{NFS: &v1.NFSVolumeSource{Server: "nfs", Path: "/"}}},
+				{Name: "cm", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{}}},
+			},
+			InitContainers: []v1.Container{{
+				Name: "init",
+				SecurityContext: &v1.SecurityContext{
+					SELinuxOptions: &v1.SELinuxOptions{Type: "spc_t"},
+					ProcMount:      &unmasked,
+				},
+			}},
+			Containers: []v1.Container{
+				{
+					Name:  "c1",
+					Ports: []v1.ContainerPort{{ContainerPort: 80, HostPort: 8080}},
+					SecurityContext: &v1.SecurityContext{
+						Privileged:   &yes,
+						Capabilities: &v1.Capabilities{Add: []v1.Capability{"SYS_ADMIN", "CHOWN"}},
+					},
+				},
+				{
+					Name: "c2",
+					SecurityContext: &v1.SecurityContext{
+						RunAsNonRoot:             &yes,
+						AllowPrivilegeEscalation: &no,
+						SeccompProfile:           &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+						Capabilities: &v1.Capabilities{
+							Add:  []v1.Capability{"NET_BIND_SERVICE"},
+							Drop: []v1.Capability{"ALL"},
+						},
+					},
+				},
+			},
+			EphemeralContainers: []v1.EphemeralContainer{{
+				EphemeralContainerCommon: v1.EphemeralContainerCommon{
+					Name: "debug",
+					SecurityContext: &v1.SecurityContext{
+						RunAsNonRoot:             &yes,
+						AllowPrivilegeEscalation: &no,
+						SeccompProfile:           &v1.SeccompProfile{Type: v1.SeccompProfileTypeUnconfined},
+						Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+					},
+				},
+			}},
+		},
+	}
+
+	assert.ElementsMatch(t, []check{
+		{"", "host-namespaces", levelBaseline},
+		{"", "sysctls", levelBaseline},
+		{"", "run-as-user", levelRestricted},
+		{"", "host-path-volumes", levelBaseline},
+		{"", "volume-types", levelRestricted},
+
+		{"init", "capabilities", levelRestricted},
+		{"init", "selinux", levelBaseline},
+		{"init", "proc-mount", levelBaseline},
+		{"init", "seccomp", levelRestricted},
+		{"init", "privilege-escalation", levelRestricted},
+		{"init", "run-as-non-root", levelRestricted},
+
+		{"c1", "privileged", levelBaseline},
+		{"c1", "capabilities", levelBaseline},
+		{"c1", "capabilities", levelRestricted},
+		{"c1", "capabilities", levelRestricted},
+		{"c1", "host-ports", levelBaseline},
+		{"c1", "apparmor", levelBaseline},
+		{"c1", "seccomp", levelRestricted},
+		{"c1", "privilege-escalation", levelRestricted},
+		{"c1", "run-as-non-root", levelRestricted},
+
+		{"debug", "seccomp", levelBaseline},
+		{"debug", "seccomp", levelRestricted},
+	}, getChecks(evaluatePodSecurity(w)))
+}
+
+func TestPodSecurityViolationsGenerate(t *testing.T) {
+	vs, err := PodSecurityViolationsGenerate(context.TODO(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []map[string]string{
+		{
+			"check_id":      "host-namespaces",
+			"cluster_uid":   "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"detail":        "hostNetwork=true",
+			"level":         "baseline",
+			"namespace":     "default",
+			"workload_kind": "Deployment",
+			"workload_name": "privileged1",
+			"workload_uid":  "d123",
+		},
+		{
+			"check_id":      "privileged",
+			"cluster_uid":   "b7fd8e77-93de-4742-9037-5db9a01e966a",
+			"container":     "app",
+			"detail":        "privileged=true",
+			"level":         "baseline",
+			"namespace":     "default",
+			"workload_kind": "Deployment",
+			"workload_name": "privileged1",
+			"workload_uid":  "d123",
+		},
+	}, vs)
+}
@@ -32,6 +32,10 @@ func getFieldValue(field reflect.Value) string {
 	tp := field.Type()
 	kind := tp.Kind()
 
+	if isQuantity(tp) {
+		return getQuantityValue(field)
+	}
+
 	if kind == reflect.Ptr {
 		if field.IsNil() {
 			return ""
@@ -85,6 +89,7 @@ func getFieldValue(field reflect.Value) string {
 
 // ToMap returns object fields as key/value map. Field names are converted to snake case.
 // Values are converted to string. Complex value types like structures are serialized as JSON.
+// Resource quantities are converted to numbers and resource lists get additional numeric columns.
 func ToMap(obj interface{}) map[string]string {
 	item := make(map[string]string)
 	val := reflect.ValueOf(obj)
@@ -110,6 +115,7 @@ func ToMap(obj interface{}) map[string]string {
 			if str != "" {
 				item[key] = str
 			}
+			addQuantityValues(item, key, field)
 		}
 	}
 
@@ -121,6 +127,9 @@ func getFieldSchema(name string, field reflect.Value) table.ColumnDefinition {
 	kind := tp.Kind()
 	key := makeKey(name)
 
+	if isQuantity(tp) {
+		return table.BigIntColumn(key)
+	}
 	if kind == reflect.Ptr {
 		tp = field.Type().Elem()
 		kind = tp.Kind()
@@ -166,8 +175,21 @@ func GetSchema(obj interface{}) []table.ColumnDefinition {
 			schema = append(schema, s...)
 		} else {
 			schema = append(schema, getFieldSchema(name, field))
+			schema = append(schema, getQuantitySchema(makeKey(name), field.Type())...)
 		}
 	}
 
 	return schema
 }
+
+// GetConstraint returns the expression of the first equality constraint on the given column from the query.
+func GetConstraint(queryContext table.QueryContext, column string) (string, bool) {
+	if cl, ok := queryContext.Constraints[column]; ok {
+		for _, c := range cl.Constraints {
+			if c.Operator == table.OperatorEquals {
+				return c.Expression, true
+			}
+		}
+	}
+	return "", false
+}
@@ -72,6 +72,8 @@ func TestGetSchema(t *testing.T) {
 		table.IntegerColumn("enable_service_links"),
 		table.TextColumn("preemption_policy"),
 		table.TextColumn("overhead"),
+		table.BigIntColumn("overhead_cpu_millicores"),
+		table.BigIntColumn("overhead_memory_bytes"),
 		table.TextColumn("topology_spread_constraints"),
 		table.IntegerColumn("set_hostname_as_fqdn"),
 	}, GetSchema(CommonPodFields{}))
@@ -188,3 +190,23 @@ func TestToMap(t *testing.T) {
 			},
 		}))
 }
+
+func TestGetConstraint(t *testing.T) {
+	qc := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"name": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorLike, Expression: "a%"},
+					{Operator: table.OperatorEquals, Expression: "abc"},
+				},
+			},
+		},
+	}
+
+	v, ok := GetConstraint(qc, "name")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", v)
+
+	_, ok = GetConstraint(qc, "namespace")
+	assert.False(t, ok)
+}
@@ -0,0 +1,131 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package workload
+
+import (
+	"context"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/Uptycs/kubequery/internal/k8s/apps"
+	"github.com/Uptycs/kubequery/internal/k8s/batch"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of workloads that run pods.
+const (
+	KindPod         = "Pod"
+	KindDeployment  = "Deployment"
+	KindDaemonSet   = "DaemonSet"
+	KindStatefulSet = "StatefulSet"
+	KindReplicaSet  = "ReplicaSet"
+	KindJob         = "Job"
+	KindCronJob     = "CronJob"
+)
+
+// Workload is a pod or an object with a pod template.
+type Workload struct {
+	Kind string
+	// ObjectMeta is the metadata of the workload object.
+	ObjectMeta metav1.ObjectMeta
+	// Template is the metadata of the pod template. It is the same as ObjectMeta for pods.
+	Template metav1.ObjectMeta
+	Spec     v1.PodSpec
+	// PodStatus is the status of pods. It is nil for other kinds.
+	PodStatus *v1.PodStatus
+}
+
+// ListPods returns all kubernetes pods in all namespaces. It lives here instead of the core package, because core
+// builds on workloads and cannot be imported by this package.
+func ListPods(ctx context.Context) ([]v1.Pod, error) {
+	options := metav1.ListOptions{}
+	results := make([]v1.Pod, 0)
+
+	for {
+		pods, err := k8s.GetClient(ctx).CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, pods.Items...)
+
+		if pods.Continue == "" {
+			break
+		}
+		options.Continue = pods.Continue
+	}
+
+	return results, nil
+}
+
+// List returns all pods and all workloads with pod templates in all namespaces.
+func List(ctx context.Context) ([]Workload, error) {
+	results := make([]Workload, 0)
+
+	pods, err := ListPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		p := &pods[i]
+		results = append(results, Workload{Kind: KindPod, ObjectMeta: p.ObjectMeta, Template: p.ObjectMeta, Spec: p.Spec, PodStatus: &p.Status})
+	}
+
+	ds, err := apps.ListDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		results = append(results, Workload{Kind: KindDeployment, ObjectMeta: d.ObjectMeta, Template: d.Spec.Template.ObjectMeta, Spec: d.Spec.Template.Spec})
+	}
+
+	dss, err := apps.ListDaemonSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range dss {
+		results = append(results, Workload{Kind: KindDaemonSet, ObjectMeta: ds.ObjectMeta, Template: ds.Spec.Template.ObjectMeta, Spec: ds.Spec.Template.Spec})
+	}
+
+	sss, err := apps.ListStatefulSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range sss {
+		results = append(results, Workload{Kind: KindStatefulSet, ObjectMeta: ss.ObjectMeta, Template: ss.Spec.Template.ObjectMeta, Spec: ss.Spec.Template.Spec})
+	}
+
+	rss, err := apps.ListReplicaSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range rss {
+		results = append(results, Workload{Kind: KindReplicaSet, ObjectMeta: rs.ObjectMeta, Template: rs.Spec.Template.ObjectMeta, Spec: rs.Spec.Template.Spec})
+	}
+
+	jobs, err := batch.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		results = append(results, Workload{Kind: KindJob, ObjectMeta: j.ObjectMeta, Template: j.Spec.Template.ObjectMeta, Spec: j.Spec.Template.Spec})
+	}
+
+	cjs, err := batch.ListCronJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cj := range cjs {
+		template := cj.Spec.JobTemplate.Spec.Template
+		results = append(results, Workload{Kind: KindCronJob, ObjectMeta: cj.ObjectMeta, Template: template.ObjectMeta, Spec: template.Spec})
+	}
+
+	return results, nil
+}
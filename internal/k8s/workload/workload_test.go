@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2020-present, The kubequery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package workload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/kubequery/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestList(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "n1", UID: types.UID(name)}
+	}
+	template := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", Image: "nginx"}}},
+	}
+
+	k8s.SetClient(fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: meta("p"), Spec: template.Spec, Status: v1.PodStatus{Phase: v1.PodRunning}},
+		&appsv1.Deployment{ObjectMeta: meta("d"), Spec: appsv1.DeploymentSpec{Template: template}},
+		&appsv1.DaemonSet{ObjectMeta: meta("ds"), Spec: appsv1.DaemonSetSpec{Template: template}},
+		&appsv1.StatefulSet{ObjectMeta: meta("ss"), Spec: appsv1.StatefulSetSpec{Template: template}},
+		&appsv1.ReplicaSet{ObjectMeta: meta("rs"), Spec: appsv1.ReplicaSetSpec{Template: template}},
+		&batchv1.Job{ObjectMeta: meta("j"), Spec: batchv1.JobSpec{Template: template}},
+		&v1beta1.CronJob{ObjectMeta: meta("cj"), Spec: v1beta1.CronJobSpec{JobTemplate: v1beta1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}}}},
+	), types.UID("c123"))
+	defer k8s.SetClient(fake.NewSimpleClientset(), types.UID(""))
+
+	ws, err := List(context.TODO())
+	assert.Nil(t, err)
+
+	kinds := make(map[string]string)
+	for _, w := range ws {
+		kinds[w.ObjectMeta.Name] = w.Kind
+		assert.Equal(t, "nginx", w.Spec.Containers[0].Image)
+		if w.Kind == KindPod {
+			assert.Equal(t, v1.PodRunning, w.PodStatus.Phase)
+		} else {
+			assert.Equal(t, "web", w.Template.Labels["app"])
+			assert.Nil(t, w.PodStatus)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"p":  KindPod,
+		"d":  KindDeployment,
+		"ds": KindDaemonSet,
+		"ss": KindStatefulSet,
+		"rs": KindReplicaSet,
+		"j":  KindJob,
+		"cj": KindCronJob,
+	}, kinds)
+}